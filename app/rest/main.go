@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"github.com/hyperledger/fabric-gateway/pkg/identity"
 	_ "github.com/izqalan/fabric-voting/app/docs"
 	r "github.com/izqalan/fabric-voting/app/routes"
+	"github.com/joho/godotenv"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"google.golang.org/grpc"
@@ -27,23 +29,58 @@ import (
 
 // @host      localhost:8081
 // @BasePath  /api/v1
-const (
-	mspID        = "Org1MSP"
-	cryptoPath   = "../../test-network/organizations/peerOrganizations/org1.example.com"
-	certPath     = cryptoPath + "/users/User1@org1.example.com/msp/signcerts/cert.pem"
-	keyPath      = cryptoPath + "/users/User1@org1.example.com/msp/keystore/"
-	tlsCertPath  = cryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt"
-	peerEndpoint = "localhost:7051"
-	gatewayPeer  = "peer0.org1.example.com"
-)
+
+// connection settings of the gateway identity
+type gatewayConfig struct {
+	mspID        string
+	certPath     string
+	keyPath      string
+	tlsCertPath  string
+	peerEndpoint string
+	gatewayPeer  string
+}
+
+// test-network peer endpoints by organization
+var testNetworkPeers = map[string]string{
+	"org1": "localhost:7051",
+	"org2": "localhost:9051",
+}
+
+// read the gateway settings once .env has been loaded
+// the gateway connects as Org1's User1 by default, another organization runs
+// its own instance with FABRIC_ORG, eg to certify election results, and
+// FABRIC_USER selects another enrolled identity such as a committee member
+// paths and the peer are derived from the organization unless set explicitly
+func loadGatewayConfig() gatewayConfig {
+	org := strings.ToLower(envOrDefault("FABRIC_ORG", "org1"))
+	user := envOrDefault("FABRIC_USER", "User1")
+	domain := org + ".example.com"
+	cryptoPath := envOrDefault("CRYPTO_PATH", "../../test-network/organizations/peerOrganizations/"+domain)
+	userPath := cryptoPath + "/users/" + user + "@" + domain + "/msp"
+	gatewayPeer := envOrDefault("GATEWAY_PEER", "peer0."+domain)
+	return gatewayConfig{
+		mspID:        envOrDefault("MSP_ID", strings.ToUpper(org[:1])+org[1:]+"MSP"),
+		certPath:     envOrDefault("CERT_PATH", userPath+"/signcerts/cert.pem"),
+		keyPath:      envOrDefault("KEY_PATH", userPath+"/keystore/"),
+		tlsCertPath:  envOrDefault("TLS_CERT_PATH", cryptoPath+"/peers/"+gatewayPeer+"/tls/ca.crt"),
+		peerEndpoint: envOrDefault("PEER_ENDPOINT", testNetworkPeers[org]),
+		gatewayPeer:  gatewayPeer,
+	}
+}
 
 func main() {
+	// settings may come from .env, which must be loaded before they are read
+	if err := godotenv.Load(".env"); err != nil {
+		fmt.Println("Error loading .env file")
+	}
+	config := loadGatewayConfig()
+
 	// The gRPC client connection should be shared by all Gateway connections to this endpoint
-	clientConnection := newGrpcConnection()
+	clientConnection := newGrpcConnection(config)
 	defer clientConnection.Close()
 
-	id := newIdentity()
-	sign := newSign()
+	id := newIdentity(config)
+	sign := newSign(config)
 
 	// Create a Gateway connection for a specific client identity
 	gw, err := client.Connect(
@@ -76,7 +113,8 @@ func main() {
 	contract := network.GetContract(chaincodeName)
 
 	// Rest Endpoints
-	r := r.SetupRouter(contract)
+	// the signer is also used to certify election results for this organization
+	r := r.SetupRouter(contract, sign)
 
 	// Swagger Endpoints
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -84,17 +122,17 @@ func main() {
 }
 
 // newGrpcConnection creates a gRPC connection to the Gateway server.
-func newGrpcConnection() *grpc.ClientConn {
-	certificate, err := loadCertificate(tlsCertPath)
+func newGrpcConnection(config gatewayConfig) *grpc.ClientConn {
+	certificate, err := loadCertificate(config.tlsCertPath)
 	if err != nil {
 		panic(err)
 	}
 
 	certPool := x509.NewCertPool()
 	certPool.AddCert(certificate)
-	transportCredentials := credentials.NewClientTLSFromCert(certPool, gatewayPeer)
+	transportCredentials := credentials.NewClientTLSFromCert(certPool, config.gatewayPeer)
 
-	connection, err := grpc.Dial(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+	connection, err := grpc.Dial(config.peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
 	if err != nil {
 		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
 	}
@@ -102,13 +140,13 @@ func newGrpcConnection() *grpc.ClientConn {
 	return connection
 }
 
-func newIdentity() *identity.X509Identity {
-	certificate, err := loadCertificate(certPath)
+func newIdentity(config gatewayConfig) *identity.X509Identity {
+	certificate, err := loadCertificate(config.certPath)
 	if err != nil {
 		panic(err)
 	}
 
-	id, err := identity.NewX509Identity(mspID, certificate)
+	id, err := identity.NewX509Identity(config.mspID, certificate)
 	if err != nil {
 		panic(err)
 	}
@@ -125,12 +163,12 @@ func loadCertificate(filename string) (*x509.Certificate, error) {
 }
 
 // creates a function that generates a digital signature from a message digest using a private key.
-func newSign() identity.Sign {
-	files, err := os.ReadDir(keyPath)
+func newSign(config gatewayConfig) identity.Sign {
+	files, err := os.ReadDir(config.keyPath)
 	if err != nil {
 		panic(fmt.Errorf("failed to read private key directory: %w", err))
 	}
-	privateKeyPEM, err := os.ReadFile(path.Join(keyPath, files[0].Name()))
+	privateKeyPEM, err := os.ReadFile(path.Join(config.keyPath, files[0].Name()))
 
 	if err != nil {
 		panic(fmt.Errorf("failed to read private key file: %w", err))
@@ -148,3 +186,11 @@ func newSign() identity.Sign {
 
 	return sign
 }
+
+// envOrDefault returns the value of an environment variable, or defaultValue if it is not set
+func envOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
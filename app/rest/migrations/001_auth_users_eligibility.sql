@@ -0,0 +1,6 @@
+-- eligibility attributes checked against an election's eligibility rule
+-- students without them only match elections whose rule leaves them unrestricted
+ALTER TABLE auth.users
+    ADD COLUMN IF NOT EXISTS faculty text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS year_of_study integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS programme text NOT NULL DEFAULT '';
@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// change to an election's name or dates as recorded on the ledger
+type amendment struct {
+	AmendmentID string              `json:"amendmentID"`
+	ElectionID  string              `json:"electionID"`
+	Target      string              `json:"target"`
+	From        string              `json:"from"`
+	To          string              `json:"to"`
+	ReasonCode  string              `json:"reasonCode,omitempty"`
+	Reason      string              `json:"reason,omitempty"`
+	ProposedBy  string              `json:"proposedBy"`
+	ProposedAt  string              `json:"proposedAt"`
+	Approvals   []amendmentApproval `json:"approvals"`
+	Status      string              `json:"status"`
+	AppliedAt   string              `json:"appliedAt,omitempty"`
+}
+
+type amendmentApproval struct {
+	Identity   string `json:"identity"`
+	ApprovedAt string `json:"approvedAt"`
+}
+
+// @Summary Approve an election amendment
+// @Description Approve a pending amendment as the gateway's identity, which must carry the committee role, see registerCommittee.sh and FABRIC_USER. The amendment is applied once two distinct committee identities have approved it.
+// @Tags Election
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Param amendmentID path string true "Amendment ID"
+// @Success 200 {string} string "Amendment approved"
+// @Router /election/{electionID}/amendments/{amendmentID}/approve [post]
+func approveAmendment(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+	amendmentID := c.Param("amendmentID")
+
+	result, err := contract.SubmitTransaction("approveAmendment", electionID, amendmentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var amendment amendment
+	json.Unmarshal(result, &amendment)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Amendment approved. Txn committed successfully.",
+		"status":  http.StatusOK,
+		"data":    amendment,
+	})
+}
+
+// @Summary Get election amendments
+// @Description Get every amendment recorded for an election with its reason, approvals and status
+// @Tags Election
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Amendments"
+// @Router /election/{electionID}/amendments [get]
+func getAmendments(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+	result, err := contract.EvaluateTransaction("getAmendments", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amendments := []amendment{}
+	json.Unmarshal(result, &amendments)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Amendments retrieved.",
+		"status":  http.StatusOK,
+		"data":    amendments,
+	})
+}
@@ -0,0 +1,153 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// student account in the Postgres auth database
+type authUser struct {
+	tableName   struct{} `pg:"auth.users"`
+	Email       string
+	Password    string
+	Id          int
+	Faculty     string
+	YearOfStudy int
+	Programme   string
+}
+
+// attributes checked by the chaincode against an election's eligibility rule
+type voterAttributes struct {
+	Faculty     string `json:"faculty"`
+	YearOfStudy int    `json:"yearOfStudy"`
+	Programme   string `json:"programme"`
+}
+
+// voter attributes of a user as the JSON document the chaincode expects
+func attributesOf(u authUser) string {
+	attributes, _ := json.Marshal(voterAttributes{
+		Faculty:     u.Faculty,
+		YearOfStudy: u.YearOfStudy,
+		Programme:   u.Programme,
+	})
+	return string(attributes)
+}
+
+// connect to the auth database configured by DB_STRING
+func connectAuthDB() (*pg.DB, error) {
+	opt, err := pg.ParseURL(goDotEnvVariable("DB_STRING"))
+	if err != nil {
+		return nil, err
+	}
+
+	db := pg.Connect(opt)
+	if db == nil {
+		return nil, fmt.Errorf("error connecting to database")
+	}
+	return db, nil
+}
+
+// no user matches the email and password
+var errWrongCredentials = errors.New("Wrong email or password")
+
+// get user with email and salted password
+// returns errWrongCredentials when no user matches, any other error comes
+// from the database, eg auth.users missing the columns added by
+// migrations/001_auth_users_eligibility.sql
+func authenticateUser(db *pg.DB, email string, password string) (authUser, error) {
+	// unsalt password using pgcrypto
+	var u authUser
+	err := db.Model(&u).
+		Where("email = ?", email).
+		Where("password = crypt(?, password)", password).
+		Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return u, errWrongCredentials
+	}
+	if err != nil {
+		return u, fmt.Errorf("failed to query auth database: %w", err)
+	}
+	return u, nil
+}
+
+// HTTP status and message for a failed authenticateUser
+func authErrorResponse(err error) (int, string) {
+	if errors.Is(err, errWrongCredentials) {
+		return http.StatusBadRequest, err.Error()
+	}
+	return http.StatusInternalServerError, err.Error()
+}
+
+// no user has the email
+var errUserNotFound = errors.New("User not found")
+
+// get user by email, used to name another student such as a delegate
+func findUserByEmail(db *pg.DB, email string) (authUser, error) {
+	var u authUser
+	err := db.Model(&u).
+		Where("email = ?", email).
+		Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return u, errUserNotFound
+	}
+	if err != nil {
+		return u, fmt.Errorf("failed to query auth database: %w", err)
+	}
+	return u, nil
+}
+
+// get every user matching an election's eligibility rule
+// mirrors the check the chaincode makes when a ballot is cast
+func findEligibleUsers(db *pg.DB, rule *eligibilityRule) ([]authUser, error) {
+	var users []authUser
+	err := db.Model(&users).
+		Column("id", "faculty", "year_of_study", "programme").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	eligible := []authUser{}
+	for _, u := range users {
+		if isEligible(rule, u) {
+			eligible = append(eligible, u)
+		}
+	}
+	return eligible, nil
+}
+
+func isEligible(rule *eligibilityRule, u authUser) bool {
+	if rule == nil {
+		return true
+	}
+	if len(rule.Faculties) > 0 && !containsFold(rule.Faculties, u.Faculty) {
+		return false
+	}
+	if len(rule.YearsOfStudy) > 0 {
+		eligible := false
+		for _, year := range rule.YearsOfStudy {
+			eligible = eligible || year == u.YearOfStudy
+		}
+		if !eligible {
+			return false
+		}
+	}
+	if len(rule.Programmes) > 0 && !containsFold(rule.Programmes, u.Programme) {
+		return false
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	// register the GIF decoder for image.Decode
+	_ "image/gif"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+const (
+	// largest avatar file accepted for upload
+	maxAvatarSize = 5 << 20
+	// largest avatar dimensions decoded, guards against decompression bombs
+	maxAvatarPixels = 4096 * 4096
+	// avatars are scaled down to fit in a square of this size
+	avatarSize = 512
+)
+
+// image types accepted for avatars, by sniffed content type
+var avatarContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// @Summary Upload Candidate Avatar
+// @Description Upload a JPEG, PNG or GIF avatar. It is resized to fit 512x512, stored, and its SHA-256 recorded on the candidate. Only allowed before the candidate's elections open. The gateway identity must be the candidate, by its studentID attribute, or a committee member
+// @Tags Candidate
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param studentID path string true "Student ID"
+// @Param file formData file true "Avatar image"
+// @Success 200 {string} string "Avatar uploaded"
+// @Router /candidate/by-id/{studentID}/avatar [post]
+func uploadCandidateAvatar(contract *client.Contract, c *gin.Context) {
+	studentID := c.Param("studentID")
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if file.Size > maxAvatarSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("avatar is larger than %d bytes", maxAvatarSize)})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxAvatarSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(data) > maxAvatarSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("avatar is larger than %d bytes", maxAvatarSize)})
+		return
+	}
+
+	// the file name and declared type are not trusted, the bytes are sniffed
+	if !avatarContentTypes[http.DetectContentType(data)] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "avatar must be a JPEG, PNG or GIF image"})
+		return
+	}
+	avatar, contentType, err := resizeAvatar(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	avatarAsBytes, _ := json.Marshal(gin.H{
+		"avatar": fmt.Sprintf("/api/v1/candidate/by-id/%s/avatar", studentID),
+		"hash":   sha256Hex(avatar),
+	})
+	// evaluate first so an unknown candidate or a rejected change never
+	// leaves an orphaned blob behind, the blob is content addressed so a
+	// failed submit is reused when the upload is retried
+	_, err = contract.EvaluateTransaction("setCandidateAvatar", studentID, string(avatarAsBytes))
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = newContentStore("avatars").put(avatar, contentType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := contract.SubmitTransaction("setCandidateAvatar", studentID, string(avatarAsBytes))
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Avatar uploaded. Txn committed successfully.",
+		"status":  http.StatusOK,
+		"data":    response,
+	})
+}
+
+// @Summary Get Candidate Avatar
+// @Description Serve a candidate's uploaded avatar after checking it against the SHA-256 on the ledger
+// @Tags Candidate
+// @Produce  image/jpeg
+// @Produce  image/png
+// @Param studentID path string true "Student ID"
+// @Success 200 {file} file "Avatar"
+// @Router /candidate/by-id/{studentID}/avatar [get]
+func getCandidateAvatar(contract *client.Contract, c *gin.Context) {
+	studentID := c.Param("studentID")
+
+	result, err := contract.EvaluateTransaction("getCandidateById", studentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	var candidate struct {
+		AvatarHash string `json:"avatarHash"`
+	}
+	json.Unmarshal(result, &candidate)
+	if candidate.AvatarHash == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "candidate has no uploaded avatar"})
+		return
+	}
+
+	data, err := newContentStore("avatars").get(candidate.AvatarHash)
+	if err == errBlobNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "avatar is missing from the store"})
+		return
+	}
+	if err == errBlobMismatch {
+		c.JSON(http.StatusConflict, gin.H{"error": "stored avatar does not match the ledger hash"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("X-Content-SHA256", candidate.AvatarHash)
+	c.Header("X-Ledger-Verified", "true")
+	c.Data(http.StatusOK, http.DetectContentType(data), data)
+}
+
+// decode an avatar, scale it down to fit avatarSize and re-encode it
+// re-encoding also drops any metadata embedded in the upload
+// JPEG stays JPEG, PNG and GIF become PNG
+func resizeAvatar(data []byte) ([]byte, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("avatar is not a valid image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxAvatarPixels {
+		return nil, "", fmt.Errorf("avatar dimensions %dx%d are too large", config.Width, config.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("avatar is not a valid image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > avatarSize || height > avatarSize {
+		if width >= height {
+			width, height = avatarSize, maxInt(1, height*avatarSize/bounds.Dx())
+		} else {
+			width, height = maxInt(1, width*avatarSize/bounds.Dy()), avatarSize
+		}
+	}
+	dst := scaleImage(src, width, height)
+
+	var out bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&out, dst, &jpeg.Options{Quality: 90})
+		return out.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(&out, dst)
+	return out.Bytes(), "image/png", err
+}
+
+// scale an image by averaging the source pixels covered by each destination pixel
+func scaleImage(src image.Image, width int, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	if width == bounds.Dx() && height == bounds.Dy() {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*bounds.Dy()/height, maxInt((y+1)*bounds.Dy()/height, y*bounds.Dy()/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*bounds.Dx()/width, maxInt((x+1)*bounds.Dx()/width, x*bounds.Dx()/width+1)
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					p := rgba.RGBAAt(sx, sy)
+					r += int(p.R)
+					g += int(p.G)
+					b += int(p.B)
+					a += int(p.A)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
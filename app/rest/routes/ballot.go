@@ -0,0 +1,145 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"gopkg.in/yaml.v3"
+)
+
+// an election with its positions and candidates, uploaded as one JSON or YAML document
+// field names are the same in both formats, eg
+//
+//	electionName: Student Council 2024
+//	startDate: 2024-05-01T00:00:00Z
+//	endDate: 2024-05-03T00:00:00Z
+//	options:
+//	  tieBreak: runoff
+//	positions:
+//	  - position: President
+//	    candidates:
+//	      - name: Aisyah
+//	        studentID: "2020123456"
+//	        faculty: FSKM
+type ballotDefinition struct {
+	BallotID     string               `json:"ballotID" yaml:"ballotID"`
+	ElectionName string               `json:"electionName" yaml:"electionName"`
+	StartDate    string               `json:"startDate" yaml:"startDate"`
+	EndDate      string               `json:"endDate" yaml:"endDate"`
+	Options      electionOptions      `json:"options" yaml:"options"`
+	Positions    []positionDefinition `json:"positions" yaml:"positions"`
+}
+
+// each position becomes its own election, electionID is assigned on upload
+type positionDefinition struct {
+	ElectionID string `json:"electionID" yaml:"electionID"`
+	Position   string `json:"position" yaml:"position"`
+	// overrides the ballot's eligibility rule for this position
+	Eligibility *eligibilityRule `json:"eligibility,omitempty" yaml:"eligibility,omitempty"`
+	// faculty ID, restricts this position to one constituency
+	Constituency string                `json:"constituency,omitempty" yaml:"constituency,omitempty"`
+	Candidates   []candidateDefinition `json:"candidates" yaml:"candidates"`
+}
+
+type candidateDefinition struct {
+	Name      string `json:"name" yaml:"name"`
+	StudentID string `json:"studentID" yaml:"studentID"`
+	Faculty   string `json:"faculty" yaml:"faculty"`
+	Party     string `json:"party" yaml:"party"`
+	PartyID   string `json:"partyID" yaml:"partyID"`
+}
+
+// @Summary Upload a ballot definition
+// @Description Create an election's positions and candidates from one JSON or YAML document.
+// @Description Everything is applied in a single transaction, so nothing is created if any part is invalid.
+// @Description With dryRun=true the definition is checked and the changes it would make are returned without committing.
+// @Tags Election
+// @Accept  json
+// @Accept  x-yaml
+// @Produce  json
+// @Param dryRun query bool false "Validate without committing"
+// @Body  {object} ballotDefinition
+// @Success 200 {string} string "Ballot definition applied"
+// @Router /ballot/definition [post]
+func uploadBallotDefinition(contract *client.Contract, c *gin.Context) {
+	definition, err := parseBallotDefinition(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(definition.Positions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one position is required"})
+		return
+	}
+
+	// generate IDs using timestamp like createElection
+	// eg ballot.1621234567 with elections election.1621234567.1, election.1621234567.2
+	// a ballot with a single position keeps the plain election.<timestamp> ID
+	currentTime := time.Now()
+	definition.BallotID = fmt.Sprintf("ballot.%d", currentTime.Unix())
+	for i := range definition.Positions {
+		if len(definition.Positions) == 1 {
+			definition.Positions[i].ElectionID = fmt.Sprintf("election.%d", currentTime.Unix())
+		} else {
+			definition.Positions[i].ElectionID = fmt.Sprintf("election.%d.%d", currentTime.Unix(), i+1)
+		}
+	}
+	createdAt := currentTime.UTC().String()
+	definitionAsBytes, _ := json.Marshal(definition)
+
+	dryRun := c.Query("dryRun") == "true"
+	var result []byte
+	if dryRun {
+		// evaluated on a single peer and never sent for ordering
+		result, err = contract.EvaluateTransaction("applyBallotDefinition", string(definitionAsBytes), createdAt)
+	} else {
+		result, err = contract.SubmitTransaction("applyBallotDefinition", string(definitionAsBytes), createdAt)
+	}
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var changes []interface{}
+	json.Unmarshal(result, &changes)
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Dry run. No changes were committed.",
+			"status":  http.StatusOK,
+			"data":    changes,
+		})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Ballot definition applied. Txn committed successfully.",
+		"status":  http.StatusCreated,
+		"data": gin.H{
+			"ballotID": definition.BallotID,
+			"changes":  changes,
+		},
+	})
+}
+
+// read a ballot definition in JSON or YAML
+// JSON is valid YAML, so both are decoded with the YAML decoder, which also
+// accepts unquoted numeric student IDs
+func parseBallotDefinition(body io.Reader) (*ballotDefinition, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	definition := &ballotDefinition{}
+	if err := yaml.Unmarshal(data, definition); err != nil {
+		return nil, fmt.Errorf("invalid ballot definition: %w", err)
+	}
+	return definition, nil
+}
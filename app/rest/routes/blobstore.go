@@ -0,0 +1,227 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// storage for uploaded files such as candidate documents and avatars
+// BLOB_BACKEND selects the backend: fs (default) or s3
+type blobStore interface {
+	put(key string, data []byte, contentType string) error
+	get(key string) ([]byte, error)
+}
+
+var errBlobNotFound = errors.New("blob not found")
+
+func newBlobStore() blobStore {
+	if goDotEnvVariable("BLOB_BACKEND") == "s3" {
+		region := goDotEnvVariable("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		return &s3BlobStore{
+			endpoint:  strings.TrimSuffix(goDotEnvVariable("S3_ENDPOINT"), "/"),
+			bucket:    goDotEnvVariable("S3_BUCKET"),
+			region:    region,
+			accessKey: goDotEnvVariable("S3_ACCESS_KEY"),
+			secretKey: goDotEnvVariable("S3_SECRET_KEY"),
+			client:    &http.Client{Timeout: 30 * time.Second},
+		}
+	}
+	dir := goDotEnvVariable("BLOB_STORE_DIR")
+	if dir == "" {
+		dir = "blobs"
+	}
+	return &fsBlobStore{dir: dir}
+}
+
+// blobs kept as files under dir
+type fsBlobStore struct {
+	dir string
+}
+
+func (s *fsBlobStore) put(key string, data []byte, contentType string) error {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// write to a temporary file first so a failed upload never leaves a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *fsBlobStore) get(key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errBlobNotFound
+	}
+	return data, err
+}
+
+// blobs kept in an S3-compatible bucket such as MinIO
+// requests use path-style URLs, endpoint/bucket/key, signed with AWS signature v4
+type s3BlobStore struct {
+	endpoint  string
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+func (s *s3BlobStore) put(key string, data []byte, contentType string) error {
+	req, err := s.request(http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("s3 put %s: %s %s", key, res.Status, body)
+	}
+	return nil
+}
+
+func (s *s3BlobStore) get(key string) ([]byte, error) {
+	req, err := s.request(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, errBlobNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("s3 get %s: %s %s", key, res.Status, body)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// build a request signed with AWS signature v4
+func (s *s3BlobStore) request(method string, key string, body []byte) (*http.Request, error) {
+	u, err := url.Parse(s.endpoint + "/" + s.bucket + "/" + key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		"",
+		"host:" + u.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{date, s.region, "s3", "aws4_request"} {
+		signingKey = hmacSha256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKey, scope, signedHeaders, signature))
+	return req, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// store that saves each blob under its sha256, so a stored blob cannot be
+// replaced without its key no longer matching its bytes
+type contentStore struct {
+	blobs  blobStore
+	prefix string
+}
+
+func newContentStore(prefix string) *contentStore {
+	return &contentStore{blobs: newBlobStore(), prefix: prefix}
+}
+
+func (s *contentStore) key(hash string) string {
+	return s.prefix + "/" + hash[:2] + "/" + hash
+}
+
+// save data and return its sha256 hex
+func (s *contentStore) put(data []byte, contentType string) (string, error) {
+	hash := sha256Hex(data)
+	return hash, s.blobs.put(s.key(hash), data, contentType)
+}
+
+// get data by its sha256 hex, checking that the bytes still match the hash
+func (s *contentStore) get(hash string) ([]byte, error) {
+	if len(hash) != sha256.Size*2 {
+		return nil, errBlobNotFound
+	}
+	data, err := s.blobs.get(s.key(hash))
+	if err != nil {
+		return nil, err
+	}
+	if sha256Hex(data) != hash {
+		return nil, errBlobMismatch
+	}
+	return data, nil
+}
+
+var errBlobMismatch = errors.New("stored bytes do not match their hash")
@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// canonical results document of a closed election and the sha256 hex
+// each certifying organization signs
+type signedResultsDocument struct {
+	Document json.RawMessage `json:"document"`
+	Hash     string          `json:"hash"`
+}
+
+// @Summary Get Results Document
+// @Description Get the canonical results document of a closed election and the hash organizations sign to certify it
+// @Tags Election
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Results document"
+// @Router /election/{electionID}/results/document [get]
+func getResultsDocument(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+	result, err := contract.EvaluateTransaction("getResultsDocument", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var document signedResultsDocument
+	json.Unmarshal(result, &document)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Results document retrieved.",
+		"status":  http.StatusOK,
+		"data":    document,
+	})
+}
+
+// @Summary Certify Results
+// @Description Sign the results document of a closed election as this gateway's organization.
+// @Description The election is certified once the configured threshold of organizations have signed
+// @Tags Election
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Results certified"
+// @Router /election/{electionID}/certify [post]
+func certifyResults(contract *client.Contract, sign identity.Sign, c *gin.Context) {
+	electionID := c.Param("electionID")
+
+	// the document is computed by this organization's own peer
+	result, err := contract.EvaluateTransaction("getResultsDocument", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var document signedResultsDocument
+	if err := json.Unmarshal(result, &document); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if document.Hash != sha256Hex(document.Document) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "results document does not match its hash"})
+		return
+	}
+
+	digest, _ := hex.DecodeString(document.Hash)
+	signature, err := sign(digest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err = contract.SubmitTransaction("certifyResults", electionID, document.Hash, base64.StdEncoding.EncodeToString(signature))
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var certification interface{}
+	json.Unmarshal(result, &certification)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Results signed. Txn committed successfully.",
+		"status":  http.StatusOK,
+		"data":    certification,
+	})
+}
+
+// @Summary Get Certification
+// @Description Get the organizations' signatures over an election's results, each checked again on-chain, and whether the election is certified
+// @Tags Election
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Certification"
+// @Router /election/{electionID}/certification [get]
+func getCertification(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+	result, err := contract.EvaluateTransaction("getCertification", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var certification interface{}
+	json.Unmarshal(result, &certification)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Certification retrieved.",
+		"status":  http.StatusOK,
+		"data":    certification,
+	})
+}
@@ -0,0 +1,163 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// largest candidate document accepted for upload
+const maxDocumentSize = 10 << 20
+
+// document anchored to a candidate on the ledger
+type candidateDocument struct {
+	Kind        string `json:"kind"`
+	Hash        string `json:"hash"`
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	AnchoredAt  string `json:"anchoredAt,omitempty"`
+}
+
+// @Summary Upload Candidate Document
+// @Description Store a candidate document, eg their manifesto, and anchor its SHA-256 on the candidate record. The gateway identity must be the candidate, by its studentID attribute, or a committee member
+// @Tags Candidate
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param studentID path string true "Student ID"
+// @Param kind formData string false "Document kind, manifesto by default"
+// @Param file formData file true "Document"
+// @Success 201 {string} string "Document anchored"
+// @Failure 409 {string} string "Document already anchored"
+// @Router /candidate/by-id/{studentID}/documents [post]
+func uploadCandidateDocument(contract *client.Contract, c *gin.Context) {
+	studentID := c.Param("studentID")
+	kind := c.PostForm("kind")
+	if kind == "" {
+		kind = "manifesto"
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if file.Size > maxDocumentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("document is larger than %d bytes", maxDocumentSize)})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxDocumentSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(data) > maxDocumentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("document is larger than %d bytes", maxDocumentSize)})
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	document, _ := json.Marshal(candidateDocument{
+		Kind:        kind,
+		Hash:        sha256Hex(data),
+		Name:        filepath.Base(file.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	})
+	// evaluate first so a rejected anchor never leaves an orphaned blob behind
+	_, err = contract.EvaluateTransaction("anchorCandidateDocument", studentID, string(document))
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// the bytes are stored before the hash is anchored, a document on the
+	// ledger can always be served
+	_, err = newContentStore("documents").put(data, contentType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := contract.SubmitTransaction("anchorCandidateDocument", studentID, string(document))
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Document anchored. Txn committed successfully.",
+		"status":  http.StatusCreated,
+		"data":    response,
+	})
+}
+
+// @Summary Get Candidate Document
+// @Description Serve a candidate document after checking that its bytes still match the SHA-256 anchored on the ledger
+// @Tags Candidate
+// @Produce  octet-stream
+// @Param studentID path string true "Student ID"
+// @Param hash path string true "Document SHA-256"
+// @Success 200 {file} file "Document"
+// @Router /candidate/by-id/{studentID}/documents/{hash} [get]
+func getCandidateDocument(contract *client.Contract, c *gin.Context) {
+	studentID := c.Param("studentID")
+	hash := strings.ToLower(c.Param("hash"))
+
+	result, err := contract.EvaluateTransaction("getCandidateById", studentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	var candidate struct {
+		Documents []candidateDocument `json:"documents"`
+	}
+	json.Unmarshal(result, &candidate)
+
+	var anchored *candidateDocument
+	for i := range candidate.Documents {
+		if candidate.Documents[i].Hash == hash {
+			anchored = &candidate.Documents[i]
+		}
+	}
+	if anchored == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "document is not anchored to this candidate"})
+		return
+	}
+
+	data, err := newContentStore("documents").get(anchored.Hash)
+	if err == errBlobNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "document is missing from the store"})
+		return
+	}
+	if err == errBlobMismatch {
+		c.JSON(http.StatusConflict, gin.H{"error": "stored document does not match the ledger hash"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("X-Content-SHA256", anchored.Hash)
+	c.Header("X-Ledger-Verified", "true")
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", anchored.Name))
+	c.DataFromReader(http.StatusOK, int64(len(data)), anchored.ContentType, bytes.NewReader(data), nil)
+}
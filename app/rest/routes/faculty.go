@@ -0,0 +1,140 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// faculty registered on the ledger, the constituency of faculty-restricted contests
+type faculty struct {
+	FacultyID string   `json:"facultyID"`
+	Code      string   `json:"code" binding:"required"`
+	Name      string   `json:"name" binding:"required"`
+	Aliases   []string `json:"aliases"`
+}
+
+// check a free-text faculty from auth.users against the faculty's code,
+// name and aliases, ignoring case, spaces and punctuation like the chaincode
+func (f faculty) matches(name string) bool {
+	key := facultyKey(name)
+	if key == "" {
+		return false
+	}
+	for _, alias := range append([]string{f.Code, f.Name}, f.Aliases...) {
+		if key == facultyKey(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+func facultyKey(name string) string {
+	var key strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			key.WriteRune(r)
+		}
+	}
+	return key.String()
+}
+
+func getFaculty(contract *client.Contract, facultyID string) (*faculty, error) {
+	result, err := contract.EvaluateTransaction("getAllFaculties")
+	if err != nil {
+		return nil, err
+	}
+	var faculties []faculty
+	json.Unmarshal(result, &faculties)
+	for _, f := range faculties {
+		if f.FacultyID == facultyID {
+			return &f, nil
+		}
+	}
+	return nil, fmt.Errorf("faculty does not exist: %s", facultyID)
+}
+
+// @Summary Register Faculty
+// @Description Register a faculty. Its ID is faculty.<code> and it can be used as the constituency of an election. The gateway identity must carry the committee role
+// @Tags Faculty
+// @Accept  json
+// @Produce  json
+// @Body  {object} faculty
+// @Success 201 {string} string "Faculty registered"
+// @Failure 409 {string} string "Faculty already registered"
+// @Router /faculty [post]
+func registerFaculty(contract *client.Contract, c *gin.Context) {
+	var faculty faculty
+	if err := c.ShouldBindJSON(&faculty); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	facultyAsBytes, _ := json.Marshal(faculty)
+
+	result, err := contract.SubmitTransaction("registerFaculty", faculty.Code, string(facultyAsBytes))
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Faculty registered. Txn committed successfully.",
+		"status":  http.StatusCreated,
+		"data":    response,
+	})
+}
+
+// @Summary Get all Faculties
+// @Description Get every registered faculty
+// @Tags Faculty
+// @Produce  json
+// @Success 200 {string} string "Faculties fetched"
+// @Router /faculty [get]
+func getAllFaculties(contract *client.Contract, c *gin.Context) {
+	result, err := contract.EvaluateTransaction("getAllFaculties")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Faculties fetched",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Get Constituency Results
+// @Description Election results grouped by constituency. Elections created from a ballot definition are reported with every position of the ballot.
+// @Tags Faculty
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Constituency results fetched"
+// @Router /election/{electionID}/results/constituencies [get]
+func getConstituencyResults(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+	result, err := contract.EvaluateTransaction("getConstituencyResults", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Constituency results fetched",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
@@ -0,0 +1,194 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// nominator authenticates, the candidate is described by the remaining fields
+type nominationRequest struct {
+	Email      string `json:"email" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	ElectionID string `json:"electionID" binding:"required"`
+	Name       string `json:"name" binding:"required"`
+	StudentID  string `json:"studentID" binding:"required"`
+	Faculty    string `json:"faculty"`
+	Party      string `json:"party"`
+	PartyID    string `json:"partyID"`
+}
+
+// seconder authenticates, studentID is the nominated candidate's
+type nominationSecond struct {
+	Email      string `json:"email" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	ElectionID string `json:"electionID" binding:"required"`
+	StudentID  string `json:"studentID" binding:"required"`
+}
+
+// decision is approve or reject, a rejection needs a reason
+type nominationDecision struct {
+	ElectionID string `json:"electionID" binding:"required"`
+	StudentID  string `json:"studentID" binding:"required"`
+	Decision   string `json:"decision" binding:"required"`
+	Reason     string `json:"reason"`
+}
+
+// @Summary Submit a nomination
+// @Description Nominate a student as a candidate before the election opens. The nominator must be eligible to vote in the election.
+// @Tags Nomination
+// @Accept  json
+// @Produce  json
+// @Body  {object} nominationRequest
+// @Success 201 {string} string "Nomination submitted"
+// @Router /nomination [post]
+func submitNomination(contract *client.Contract, c *gin.Context) {
+	var request nominationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, err := connectAuthDB()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer db.Close()
+
+	u, err := authenticateUser(db, request.Email, request.Password)
+	if err != nil {
+		status, message := authErrorResponse(err)
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	details, _ := json.Marshal(candidate{
+		Name:      request.Name,
+		StudentID: request.StudentID,
+		Faculty:   request.Faculty,
+		Party:     request.Party,
+		PartyID:   request.PartyID,
+	})
+	result, err := contract.SubmitTransaction("submitNomination", request.ElectionID, string(details), strconv.Itoa(u.Id), attributesOf(u))
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var nomination interface{}
+	json.Unmarshal(result, &nomination)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Nomination submitted. Txn committed successfully.",
+		"status":  http.StatusCreated,
+		"data":    nomination,
+	})
+}
+
+// @Summary Second a nomination
+// @Description Second a pending nomination. The seconder must be eligible to vote in the election and cannot be the nominator.
+// @Tags Nomination
+// @Accept  json
+// @Produce  json
+// @Body  {object} nominationSecond
+// @Success 200 {string} string "Nomination seconded"
+// @Router /nomination/second [post]
+func secondNomination(contract *client.Contract, c *gin.Context) {
+	var second nominationSecond
+	if err := c.ShouldBindJSON(&second); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, err := connectAuthDB()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer db.Close()
+
+	u, err := authenticateUser(db, second.Email, second.Password)
+	if err != nil {
+		status, message := authErrorResponse(err)
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	result, err := contract.SubmitTransaction("secondNomination", second.ElectionID, second.StudentID, strconv.Itoa(u.Id), attributesOf(u))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var nomination interface{}
+	json.Unmarshal(result, &nomination)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Nomination seconded. Txn committed successfully.",
+		"status":  http.StatusOK,
+		"data":    nomination,
+	})
+}
+
+// @Summary Decide a nomination
+// @Description Election committee approves or rejects a nomination. Approved nominees are added to the ballot.
+// @Tags Nomination
+// @Accept  json
+// @Produce  json
+// @Body  {object} nominationDecision
+// @Success 200 {string} string "Nomination decided"
+// @Router /nomination/decision [post]
+func decideNomination(contract *client.Contract, c *gin.Context) {
+	var decision nominationDecision
+	if err := c.ShouldBindJSON(&decision); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := contract.SubmitTransaction("decideNomination", decision.ElectionID, decision.StudentID, decision.Decision, decision.Reason)
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var nomination interface{}
+	json.Unmarshal(result, &nomination)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Nomination decided. Txn committed successfully.",
+		"status":  http.StatusOK,
+		"data":    nomination,
+	})
+}
+
+// @Summary Get nominations
+// @Description Get every nomination submitted for an election with its seconders and status
+// @Tags Nomination
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Nominations"
+// @Router /nomination/{electionID} [get]
+func getNominations(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+	result, err := contract.EvaluateTransaction("getNominations", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var nominations interface{}
+	json.Unmarshal(result, &nominations)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Nominations retrieved.",
+		"status":  http.StatusOK,
+		"data":    nominations,
+	})
+}
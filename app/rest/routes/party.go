@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+type party struct {
+	Name         string `json:"name" binding:"required"`
+	Abbreviation string `json:"abbreviation" binding:"required"`
+	Logo         string `json:"logo"`
+	// sha256 hex of the party manifesto
+	ManifestoHash string `json:"manifestoHash"`
+}
+
+// @Summary Register Party
+// @Description Register a party or slate. Names and abbreviations must be unique ignoring case, spaces and punctuation. The gateway identity must carry the committee role
+// @Tags Party
+// @Accept  json
+// @Produce  json
+// @Body  {object} party
+// @Success 201 {string} string "Party registered"
+// @Failure 409 {string} string "Party already registered"
+// @Router /party [post]
+func registerParty(contract *client.Contract, c *gin.Context) {
+	var party party
+	if err := c.ShouldBindJSON(&party); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// generate partyID using timestamp like elections
+	// eg party.1621234567
+	partyID := fmt.Sprintf("party.%d", time.Now().Unix())
+	partyAsBytes, _ := json.Marshal(party)
+
+	result, err := contract.SubmitTransaction("registerParty", partyID, string(partyAsBytes))
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Party registered. Txn committed successfully.",
+		"status":  http.StatusCreated,
+		"data":    response,
+	})
+}
+
+// @Summary Get all Parties
+// @Description Get every registered party
+// @Tags Party
+// @Produce  json
+// @Success 200 {string} string "Parties fetched"
+// @Router /party [get]
+func getAllParties(contract *client.Contract, c *gin.Context) {
+	result, err := contract.EvaluateTransaction("getAllParties")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Parties fetched",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Get Party
+// @Description Get a party by partyID
+// @Tags Party
+// @Produce  json
+// @Param partyID path string true "Party ID"
+// @Success 200 {string} string "Party fetched"
+// @Router /party/{partyID} [get]
+func getPartyById(contract *client.Contract, c *gin.Context) {
+	partyID := c.Param("partyID")
+	result, err := contract.EvaluateTransaction("getPartyById", partyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Party fetched",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Get Party Results
+// @Description Votes and seats per party. Elections created from a ballot definition are aggregated across every position of the ballot.
+// @Tags Party
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Party results fetched"
+// @Router /election/{electionID}/results/parties [get]
+func getPartyResults(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+	result, err := contract.EvaluateTransaction("getPartyResults", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Party results fetched",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
@@ -1,19 +1,28 @@
 package routes
 
 import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/mail"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/go-pg/pg/v10"
 	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
 	"github.com/joho/godotenv"
+	"google.golang.org/grpc/status"
 )
 
 func goDotEnvVariable(key string) string {
@@ -28,18 +37,41 @@ func goDotEnvVariable(key string) string {
 	return os.Getenv(key)
 }
 
+// HTTP status for a failed transaction
+// the chaincode returns status 409 for requests that conflict with the ledger,
+// eg a candidate entered twice, and 404 for records that do not exist,
+// which the peers report in the error details
+func transactionErrorStatus(err error) int {
+	for _, code := range []int{http.StatusConflict, http.StatusNotFound} {
+		response := fmt.Sprintf("chaincode response %d,", code)
+		if strings.Contains(err.Error(), response) {
+			return code
+		}
+		for _, detail := range status.Convert(err).Details() {
+			if errorDetail, ok := detail.(*gateway.ErrorDetail); ok && strings.Contains(errorDetail.Message, response) {
+				return code
+			}
+		}
+	}
+	return http.StatusBadRequest
+}
+
 type newElectionValue struct {
 	Target string `json:"target"`
 	Value  string `json:"value"`
+	// required once the election has opened
+	ReasonCode string `json:"reasonCode"`
+	Reason     string `json:"reason"`
 }
 
 type candidate struct {
-	Name       string `json:"name"`
-	StudentID  string `json:"studentID"`
-	Faculty    string `json:"faculty"`
-	Party      string `json:"party"`
+	Name      string `json:"name"`
+	StudentID string `json:"studentID"`
+	Faculty   string `json:"faculty"`
+	Party     string `json:"party"`
+	// registered party, takes precedence over the free-text party
+	PartyID    string `json:"partyID"`
 	ElectionID string `json:"electionID"`
-	Avatar     string `json:"avatar"`
 }
 
 type election struct {
@@ -48,6 +80,83 @@ type election struct {
 	StartDate    string `json:"startDate"`
 	EndDate      string `json:"endDate"`
 	UpdatedAt    string `json:"updatedAt"`
+	// runoff, lot, earliestNomination or committee
+	TieBreak string `json:"tieBreak"`
+	// sha256 hex of the lot seed, required for the lot tie-break
+	SeedHash string `json:"seedHash"`
+	// percentage of candidate votes the winner must exceed, 0 for plurality
+	MajorityThreshold int `json:"majorityThreshold"`
+	// standard or weighted
+	Type string `json:"type"`
+	// voters may replace their ballot until the election closes
+	AllowRevote bool `json:"allowRevote"`
+	// who may vote, omit to let all students vote
+	Eligibility *eligibilityRule `json:"eligibility"`
+	// only accept ballots from the voter roll snapshot taken when the election opens
+	FrozenRoll bool `json:"frozenRoll"`
+	// candidates must be nominated, seconded and approved by the committee
+	Nominations bool `json:"nominations"`
+	// number of eligible students who must second a nomination
+	Seconders int `json:"seconders"`
+	// faculty ID, only voters of this faculty see and vote on the contest
+	Constituency string `json:"constituency"`
+	// push the end date back by the voting time lost while the election is suspended
+	ExtendOnResume bool `json:"extendOnResume"`
+}
+
+// number of hashed voter IDs submitted per addVoterRollEntries transaction
+const voterRollBatchSize = 1000
+
+// faculties, years of study and programmes allowed to vote
+// an empty list matches all students
+type eligibilityRule struct {
+	Faculties    []string `json:"faculties,omitempty" yaml:"faculties,omitempty"`
+	YearsOfStudy []int    `json:"yearsOfStudy,omitempty" yaml:"yearsOfStudy,omitempty"`
+	Programmes   []string `json:"programmes,omitempty" yaml:"programmes,omitempty"`
+}
+
+// options passed to createElection chaincode as JSON
+type electionOptions struct {
+	TieBreak          string           `json:"tieBreak" yaml:"tieBreak"`
+	SeedHash          string           `json:"seedHash" yaml:"seedHash"`
+	MajorityThreshold int              `json:"majorityThreshold" yaml:"majorityThreshold"`
+	Type              string           `json:"type" yaml:"type"`
+	AllowRevote       bool             `json:"allowRevote" yaml:"allowRevote"`
+	Eligibility       *eligibilityRule `json:"eligibility" yaml:"eligibility"`
+	FrozenRoll        bool             `json:"frozenRoll" yaml:"frozenRoll"`
+	Nominations       bool             `json:"nominations" yaml:"nominations"`
+	Seconders         int              `json:"seconders" yaml:"seconders"`
+	Constituency      string           `json:"constituency" yaml:"constituency"`
+	ExtendOnResume    bool             `json:"extendOnResume" yaml:"extendOnResume"`
+}
+
+// voting window for the runoff created if the election needs one
+type closeElectionRequest struct {
+	RunoffStartDate string `json:"runoffStartDate"`
+	RunoffEndDate   string `json:"runoffEndDate"`
+}
+
+// candidate details that can be changed after creation
+// fields left empty keep their current value
+type candidateProfile struct {
+	Name    string `json:"name"`
+	Faculty string `json:"faculty"`
+	Party   string `json:"party"`
+	PartyID string `json:"partyID"`
+}
+
+// withdraw or disqualify a candidate, a disqualification needs a reason
+type candidateStatus struct {
+	ElectionID string `json:"electionID" binding:"required"`
+	Reason     string `json:"reason"`
+}
+
+type tieBreakSeed struct {
+	Seed string `json:"seed" binding:"required"`
+}
+
+type tieDecision struct {
+	CandidateID string `json:"candidateID" binding:"required"`
 }
 
 type voter struct {
@@ -56,6 +165,34 @@ type voter struct {
 	Email      string `json:"email"`
 }
 
+// row of a voter import CSV: student ID, email, faculty
+// Row is the line number in the file
+type voterRow struct {
+	Row       int    `json:"-"`
+	StudentID string `json:"studentID"`
+	Email     string `json:"email"`
+	Faculty   string `json:"faculty"`
+}
+
+// outcome of a voter import row
+// status is created, exists, duplicate or invalid
+type voterRowResult struct {
+	Row       int    `json:"row"`
+	StudentID string `json:"studentID"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// number of voters registered per createVoters transaction
+const voterImportBatchSize = 500
+
+// voterID is the voter's auth.users id
+type voterWeight struct {
+	VoterID    string `json:"voterID" binding:"required"`
+	ElectionID string `json:"electionID" binding:"required"`
+	Weight     int    `json:"weight" binding:"required"`
+}
+
 type vote struct {
 	VoterID     string `json:"voterID"`
 	CandidateID string `json:"candidateID"`
@@ -69,12 +206,22 @@ type voteV2 struct {
 	ElectionID  string `json:"electionID"`
 }
 
+// delegator authenticates, the delegate is named by email
+type delegation struct {
+	Email         string `json:"email" binding:"required"`
+	Password      string `json:"password" binding:"required"`
+	DelegateEmail string `json:"delegateEmail"`
+	ElectionID    string `json:"electionID" binding:"required"`
+}
+
 type Response struct {
 	Message string `json:"message"`
 	Status  int    `json:"status"`
 }
 
-func SetupRouter(contract *client.Contract) *gin.Engine {
+// sign is the gateway identity's signer, used to certify election results
+// on behalf of its organization
+func SetupRouter(contract *client.Contract, sign identity.Sign) *gin.Engine {
 	r := gin.Default()
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -112,6 +259,30 @@ func SetupRouter(contract *client.Contract) *gin.Engine {
 		v1.GET("/candidate/:electionID", func(c *gin.Context) {
 			getCandidatesByElectionId(contract, c)
 		})
+		v1.GET("/candidate/by-id/:studentID", func(c *gin.Context) {
+			getCandidateById(contract, c)
+		})
+		v1.PUT("/candidate/by-id/:studentID", func(c *gin.Context) {
+			updateCandidate(contract, c)
+		})
+		v1.POST("/candidate/by-id/:studentID/avatar", func(c *gin.Context) {
+			uploadCandidateAvatar(contract, c)
+		})
+		v1.GET("/candidate/by-id/:studentID/avatar", func(c *gin.Context) {
+			getCandidateAvatar(contract, c)
+		})
+		v1.POST("/candidate/by-id/:studentID/documents", func(c *gin.Context) {
+			uploadCandidateDocument(contract, c)
+		})
+		v1.GET("/candidate/by-id/:studentID/documents/:hash", func(c *gin.Context) {
+			getCandidateDocument(contract, c)
+		})
+		v1.POST("/candidate/:studentID/withdraw", func(c *gin.Context) {
+			withdrawCandidate(contract, c)
+		})
+		v1.POST("/candidate/:studentID/disqualify", func(c *gin.Context) {
+			disqualifyCandidate(contract, c)
+		})
 		v1.POST("/election", func(c *gin.Context) {
 			createElection(contract, c)
 		})
@@ -121,22 +292,115 @@ func SetupRouter(contract *client.Contract) *gin.Engine {
 		v1.PUT("/election/:electionID", func(c *gin.Context) {
 			updateElection(contract, c)
 		})
+		v1.GET("/election/:electionID/results", func(c *gin.Context) {
+			getElectionResults(contract, c)
+		})
+		v1.POST("/election/:electionID/close", func(c *gin.Context) {
+			closeElection(contract, c)
+		})
+		v1.GET("/election/:electionID/results/document", func(c *gin.Context) {
+			getResultsDocument(contract, c)
+		})
+		v1.POST("/election/:electionID/certify", func(c *gin.Context) {
+			certifyResults(contract, sign, c)
+		})
+		v1.GET("/election/:electionID/certification", func(c *gin.Context) {
+			getCertification(contract, c)
+		})
+		v1.POST("/election/:electionID/cancel", func(c *gin.Context) {
+			cancelElection(contract, c)
+		})
+		v1.POST("/election/:electionID/archive", func(c *gin.Context) {
+			archiveElection(contract, c)
+		})
+		v1.POST("/election/:electionID/suspend", func(c *gin.Context) {
+			suspendElection(contract, c)
+		})
+		v1.POST("/election/:electionID/resume", func(c *gin.Context) {
+			resumeElection(contract, c)
+		})
+		v1.POST("/election/:electionID/roll/snapshot", func(c *gin.Context) {
+			snapshotVoterRoll(contract, c)
+		})
+		v1.POST("/election/:electionID/tie-break/seed", func(c *gin.Context) {
+			revealTieBreakSeed(contract, c)
+		})
+		v1.POST("/election/:electionID/tie-break/decision", func(c *gin.Context) {
+			decideTie(contract, c)
+		})
+		v1.GET("/election/:electionID/history", func(c *gin.Context) {
+			getElectionHistory(contract, c)
+		})
+		v1.GET("/election/:electionID/amendments", func(c *gin.Context) {
+			getAmendments(contract, c)
+		})
+		v1.POST("/election/:electionID/amendments/:amendmentID/approve", func(c *gin.Context) {
+			approveAmendment(contract, c)
+		})
+		v1.GET("/election/:electionID/results/parties", func(c *gin.Context) {
+			getPartyResults(contract, c)
+		})
+		v1.POST("/party", func(c *gin.Context) {
+			registerParty(contract, c)
+		})
+		v1.GET("/party", func(c *gin.Context) {
+			getAllParties(contract, c)
+		})
+		v1.GET("/party/:partyID", func(c *gin.Context) {
+			getPartyById(contract, c)
+		})
+		v1.GET("/election/:electionID/results/constituencies", func(c *gin.Context) {
+			getConstituencyResults(contract, c)
+		})
+		v1.POST("/faculty", func(c *gin.Context) {
+			registerFaculty(contract, c)
+		})
+		v1.GET("/faculty", func(c *gin.Context) {
+			getAllFaculties(contract, c)
+		})
 		v1.GET("/election", func(c *gin.Context) {
 			getAllElections(contract, c)
 		})
 		v1.POST("/voter", func(c *gin.Context) {
 			createVoter(contract, c)
 		})
+		v1.POST("/voter/import", func(c *gin.Context) {
+			importVoters(contract, c)
+		})
+		v1.POST("/voter/weight", func(c *gin.Context) {
+			setVoterWeight(contract, c)
+		})
 		v1.GET("/voters", func(c *gin.Context) {
 			getAllVoters(contract, c)
 		})
 		v1.POST("/ballot/vote", func(c *gin.Context) {
 			castVote(contract, c)
 		})
+		v1.POST("/ballot/definition", func(c *gin.Context) {
+			uploadBallotDefinition(contract, c)
+		})
+		v1.POST("/nomination", func(c *gin.Context) {
+			submitNomination(contract, c)
+		})
+		v1.POST("/nomination/second", func(c *gin.Context) {
+			secondNomination(contract, c)
+		})
+		v1.POST("/nomination/decision", func(c *gin.Context) {
+			decideNomination(contract, c)
+		})
+		v1.GET("/nomination/:electionID", func(c *gin.Context) {
+			getNominations(contract, c)
+		})
 
 	}
 	v2 := r.Group("/api/v2")
 	{
+		v2.POST("/ballot/delegate", func(c *gin.Context) {
+			delegateVote(contract, c)
+		})
+		v2.POST("/ballot/delegate/revoke", func(c *gin.Context) {
+			revokeDelegation(contract, c)
+		})
 		v2.POST("/ballot/vote", func(c *gin.Context) {
 			// Create a channel to receive the response
 			responseChan := make(chan *Response)
@@ -185,12 +449,13 @@ func helloWorld(c *gin.Context) {
 }
 
 // @Summary Create Candidate
-// @Description Create a new candidate
+// @Description Create a new candidate. Their avatar is uploaded afterwards, see /candidate/by-id/{studentID}/avatar
 // @Tags Candidate
 // @Accept  json
 // @Produce  json
-// @Body  {object} name, studentID, electionID, faculty, party, avatar
+// @Body  {object} name, studentID, electionID, faculty, party
 // @Success 200 {string} string "Candidate created"
+// @Failure 409 {string} string "Candidate already entered or standing for a conflicting position"
 // @Router /candidate [post]
 func createCandidate(contract *client.Contract, c *gin.Context) {
 
@@ -201,10 +466,11 @@ func createCandidate(contract *client.Contract, c *gin.Context) {
 		return
 	}
 
-	_, err := contract.SubmitTransaction("createCandidate", candidate.Name, candidate.StudentID, candidate.ElectionID, candidate.Faculty, candidate.Party, candidate.Avatar)
+	_, err := contract.SubmitTransaction("createCandidate", candidate.Name, candidate.StudentID, candidate.ElectionID, candidate.Faculty, candidate.Party, "", candidate.PartyID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		panic(fmt.Errorf("failed to submit transaction: %w", err))
+		// 409 when the candidate is already entered or standing for a conflicting position
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
 	}
 
 	fmt.Printf("*** Transaction committed successfully\n")
@@ -276,6 +542,138 @@ func getCandidatesByElectionId(contract *client.Contract, c *gin.Context) {
 	})
 }
 
+// @Summary Get Candidate by student ID
+// @Description Get a candidate's profile and the history of changes to it
+// @Tags Candidate
+// @Produce  json
+// @Param studentID path string true "Student ID"
+// @Success 200 {string} string "Candidate fetched"
+// @Router /candidate/by-id/{studentID} [get]
+func getCandidateById(contract *client.Contract, c *gin.Context) {
+	studentID := c.Param("studentID")
+
+	result, err := contract.EvaluateTransaction("getCandidateById", studentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	historyResult, err := contract.EvaluateTransaction("getCandidateHistory", studentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var candidate, history interface{}
+	json.Unmarshal(result, &candidate)
+	json.Unmarshal(historyResult, &history)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Candidate fetched",
+		"data":    candidate,
+		"history": history,
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Update Candidate
+// @Description Update a candidate's name, faculty or party, the avatar is uploaded instead. Fields left empty are not changed. Only allowed before the candidate's elections open. The gateway identity must be the candidate, by its studentID attribute, or a committee member
+// @Tags Candidate
+// @Accept  json
+// @Produce  json
+// @Param studentID path string true "Student ID"
+// @Body  {object} candidateProfile
+// @Success 200 {string} string "Candidate updated"
+// @Router /candidate/by-id/{studentID} [put]
+func updateCandidate(contract *client.Contract, c *gin.Context) {
+	studentID := c.Param("studentID")
+
+	var profile candidateProfile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	profileAsBytes, _ := json.Marshal(profile)
+
+	result, err := contract.SubmitTransaction("updateCandidate", studentID, string(profileAsBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var candidate interface{}
+	json.Unmarshal(result, &candidate)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Candidate updated. Txn committed successfully.",
+		"data":    candidate,
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Withdraw Candidate
+// @Description Withdraw a candidate from an election before it opens. The gateway identity must be the candidate, by its studentID attribute, or a committee member
+// @Tags Candidate
+// @Accept  json
+// @Produce  json
+// @Param studentID path string true "Student ID"
+// @Body  {object} candidateStatus
+// @Success 200 {string} string "Candidate withdrawn"
+// @Router /candidate/{studentID}/withdraw [post]
+func withdrawCandidate(contract *client.Contract, c *gin.Context) {
+	studentID := c.Param("studentID")
+
+	var status candidateStatus
+	if err := c.ShouldBindJSON(&status); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := contract.SubmitTransaction("withdrawCandidate", studentID, status.ElectionID, status.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Candidate withdrawn. Txn committed successfully.",
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Disqualify Candidate
+// @Description Election committee disqualifies a candidate. Ballots already cast for the candidate are void. The gateway identity must carry the committee role
+// @Tags Candidate
+// @Accept  json
+// @Produce  json
+// @Param studentID path string true "Student ID"
+// @Body  {object} candidateStatus
+// @Success 200 {string} string "Candidate disqualified"
+// @Router /candidate/{studentID}/disqualify [post]
+func disqualifyCandidate(contract *client.Contract, c *gin.Context) {
+	studentID := c.Param("studentID")
+
+	var status candidateStatus
+	if err := c.ShouldBindJSON(&status); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := contract.SubmitTransaction("disqualifyCandidate", studentID, status.ElectionID, status.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Candidate disqualified. Txn committed successfully.",
+		"status":  http.StatusOK,
+	})
+}
+
 // @Summary Create Election
 // @Description Create a new election
 // @Tags Election
@@ -300,7 +698,21 @@ func createElection(contract *client.Contract, c *gin.Context) {
 	// time in readable utc
 	createdAt := currentTime.UTC().String()
 
-	_, err := contract.SubmitTransaction("createElection", election.ElectionName, election.StartDate, election.EndDate, electionID, createdAt)
+	options, _ := json.Marshal(electionOptions{
+		TieBreak:          election.TieBreak,
+		SeedHash:          election.SeedHash,
+		MajorityThreshold: election.MajorityThreshold,
+		Type:              election.Type,
+		AllowRevote:       election.AllowRevote,
+		Eligibility:       election.Eligibility,
+		FrozenRoll:        election.FrozenRoll,
+		Nominations:       election.Nominations,
+		Seconders:         election.Seconders,
+		Constituency:      election.Constituency,
+		ExtendOnResume:    election.ExtendOnResume,
+	})
+
+	_, err := contract.SubmitTransaction("createElection", election.ElectionName, election.StartDate, election.EndDate, electionID, createdAt, string(options))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		panic(fmt.Errorf("failed to submit transaction: %w", err))
@@ -349,19 +761,356 @@ func getElectionById(contract *client.Contract, c *gin.Context) {
 	})
 }
 
+// @Summary Get Election History
+// @Description Every version of an election with its transaction ID, timestamp, submitting identity and the fields that changed
+// @Tags Election
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Election history fetched"
+// @Router /election/{electionID}/history [get]
+func getElectionHistory(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+	result, err := contract.EvaluateTransaction("getElectionHistory", electionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Election history fetched",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Get Election Results
+// @Description Get candidate votes, abstain and blank ballots for an election
+// @Tags Election
+// @Accept  json
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Election results fetched"
+// @Router /election/{electionID}/results [get]
+func getElectionResults(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+	result, err := contract.EvaluateTransaction("getElectionResults", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var response interface{}
+	err = json.Unmarshal(result, &response)
+	if err != nil {
+		c.JSON(http.StatusRequestTimeout, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Election results fetched",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Close Election
+// @Description Close an election after voting has ended.
+// @Description A linked runoff election is created if no candidate reached the majority threshold
+// @Description The gateway identity must carry the committee role
+// @Tags Election
+// @Accept  json
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Body  {object} runoffStartDate, runoffEndDate
+// @Success 200 {string} string "Election closed"
+// @Router /election/{electionID}/close [post]
+func closeElection(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+
+	var closeRequest closeElectionRequest
+	if err := c.ShouldBindJSON(&closeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// runoff ID is generated the same way as in createElection
+	// it is only used by the chaincode if a runoff is required
+	currentTime := time.Now()
+	runoffID := fmt.Sprintf("election.%d", currentTime.Unix())
+	createdAt := currentTime.UTC().String()
+
+	result, err := contract.SubmitTransaction("closeElection", electionID, runoffID, closeRequest.RunoffStartDate, closeRequest.RunoffEndDate, createdAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var response interface{}
+	err = json.Unmarshal(result, &response)
+	if err != nil {
+		c.JSON(http.StatusRequestTimeout, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Election closed. Txn committed successfully.",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
+
+// reason recorded on the ledger with the cancellation
+type cancelElectionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// @Summary Cancel Election
+// @Description Cancel an election before its results are declared. A cancelled election refuses every further transaction. The gateway identity must carry the committee role
+// @Tags Election
+// @Accept  json
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Body  {object} reason
+// @Success 200 {string} string "Election cancelled"
+// @Router /election/{electionID}/cancel [post]
+func cancelElection(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+
+	var cancelRequest cancelElectionRequest
+	if err := c.ShouldBindJSON(&cancelRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := contract.SubmitTransaction("cancelElection", electionID, cancelRequest.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Election cancelled. Txn committed successfully.",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Archive Election
+// @Description Archive an election once its results are final, closed and certified by the organizations, so it is left out of election listings. The gateway identity must carry the committee role
+// @Tags Election
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Election archived"
+// @Router /election/{electionID}/archive [post]
+func archiveElection(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+
+	result, err := contract.SubmitTransaction("archiveElection", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Election archived. Txn committed successfully.",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Snapshot Voter Roll
+// @Description Record every eligible student as a hashed voter ID and freeze the roll.
+// @Description Run once the election opens, earlier snapshots are rejected and ballots are rejected until the roll is frozen
+// @Description The gateway identity must carry the committee role
+// @Tags Election
+// @Accept  json
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Success 200 {string} string "Voter roll frozen"
+// @Router /election/{electionID}/roll/snapshot [post]
+func snapshotVoterRoll(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+
+	result, err := contract.EvaluateTransaction("getElectionById", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var election election
+	if err := json.Unmarshal(result, &election); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Election not found"})
+		return
+	}
+
+	db, err := connectAuthDB()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer db.Close()
+
+	users, err := findEligibleUsers(db, election.Eligibility)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// a faculty-restricted contest only rolls the constituency's voters
+	if election.Constituency != "" {
+		constituency, err := getFaculty(contract, election.Constituency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		inConstituency := []authUser{}
+		for _, u := range users {
+			if constituency.matches(u.Faculty) {
+				inConstituency = append(inConstituency, u)
+			}
+		}
+		users = inConstituency
+	}
+
+	// only hashes go on the ledger, the chaincode hashes the voter ID
+	// the same way when a ballot is cast
+	hashes := []string{}
+	for _, u := range users {
+		hash := sha256.Sum256([]byte(electionID + "." + strconv.Itoa(u.Id)))
+		hashes = append(hashes, hex.EncodeToString(hash[:]))
+	}
+	for start := 0; start < len(hashes); start += voterRollBatchSize {
+		end := start + voterRollBatchSize
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		batch, _ := json.Marshal(hashes[start:end])
+		_, err = contract.SubmitTransaction("addVoterRollEntries", electionID, string(batch))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err = contract.SubmitTransaction("freezeVoterRoll", electionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var response interface{}
+	err = json.Unmarshal(result, &response)
+	if err != nil {
+		c.JSON(http.StatusRequestTimeout, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Voter roll frozen. Txn committed successfully.",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Reveal Tie-break Seed
+// @Description Reveal the lot seed committed at election creation, once voting has ended. The gateway identity must carry the committee role
+// @Tags Election
+// @Accept  json
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Body  {object} seed
+// @Success 200 {string} string "Seed revealed"
+// @Router /election/{electionID}/tie-break/seed [post]
+func revealTieBreakSeed(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+
+	var seed tieBreakSeed
+	if err := c.ShouldBindJSON(&seed); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := contract.SubmitTransaction("revealTieBreakSeed", electionID, seed.Seed)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Seed revealed. Txn committed successfully.",
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary Decide Tie
+// @Description Record the committee's choice between tied candidates
+// @Tags Election
+// @Accept  json
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Body  {object} candidateID
+// @Success 200 {string} string "Tie decided"
+// @Router /election/{electionID}/tie-break/decision [post]
+func decideTie(contract *client.Contract, c *gin.Context) {
+	electionID := c.Param("electionID")
+
+	var decision tieDecision
+	if err := c.ShouldBindJSON(&decision); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := contract.SubmitTransaction("decideTie", electionID, decision.CandidateID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tie decided. Txn committed successfully.",
+		"status":  http.StatusOK,
+	})
+}
+
 // @Summary Get All Elections
-// @Description Get all elections
+// @Description Get all elections, or with faculty only the elections a voter of that faculty can vote in.
+// @Description Archived elections are left out unless include=archived
 // @Tags Election
 // @Accept  json
 // @Produce  json
+// @Param faculty query string false "Voter's faculty"
+// @Param include query string false "archived to list archived elections too"
 // @Success 200 {string} string "Elections fetched"
 // @Router /election [get]
 func getAllElections(contract *client.Contract, c *gin.Context) {
-	// get all elections using queryByRange function chaincode
-	result, err := contract.EvaluateTransaction("queryByRange", "election.", "election.z")
+	// with ?faculty= contests restricted to other constituencies are left out
+	include := c.Query("include")
+	var result []byte
+	var err error
+	if faculty := c.Query("faculty"); faculty != "" {
+		result, err = contract.EvaluateTransaction("getElectionsForFaculty", faculty, include)
+	} else {
+		result, err = contract.EvaluateTransaction("getElections", include)
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		panic(fmt.Errorf("failed to query transaction: %w", err))
+		return
 	}
 
 	fmt.Printf("*** Elections fetched successfully\n")
@@ -415,19 +1164,20 @@ func getAllVoters(contract *client.Contract, c *gin.Context) {
 
 // update election
 // @Summary Update Election
-// @Description Update election by electionID
+// @Description Update election by electionID. Once the election has opened a reasonCode is required and only the name and later end dates are applied straight away; other changes are recorded as pending amendments until two committee members approve them.
 // @Tags Election
 // @Accept  json
 // @Produce  json
 // @Param electionID path string true "Election ID"
-// @Body  {object} target, value
+// @Body  {object} target, value, reasonCode, reason
 // @Success 200 {string} string "Election updated"
+// @Success 202 {string} string "Amendment pending approval"
 // @Router /election/{electionID} [put]
 func updateElection(contract *client.Contract, c *gin.Context) {
 	electionID := c.Param("electionID")
 
 	// the chaincode takes electionID, target field and new value
-	// eg updateElection(electionID, "electionName", "new election name")
+	// eg updateElection(electionID, "name", "new election name", "correction", "typo")
 
 	var newElectionValue newElectionValue
 	if err := c.ShouldBindJSON(&newElectionValue); err != nil {
@@ -435,17 +1185,28 @@ func updateElection(contract *client.Contract, c *gin.Context) {
 		return
 	}
 
-	_, err := contract.SubmitTransaction("updateElection", electionID, newElectionValue.Target, newElectionValue.Value)
+	result, err := contract.SubmitTransaction("updateElection", electionID, newElectionValue.Target, newElectionValue.Value, newElectionValue.ReasonCode, newElectionValue.Reason)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		panic(fmt.Errorf("failed to submit transaction: %w", err))
+		return
 	}
 
 	fmt.Printf("*** Transaction committed successfully\n")
 
+	var amendment amendment
+	json.Unmarshal(result, &amendment)
+	if amendment.Status == "pending" {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Amendment recorded, awaiting committee approval. Txn committed successfully.",
+			"status":  http.StatusAccepted,
+			"data":    amendment,
+		})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Election updated. Txn committed successfully.",
 		"status":  http.StatusOK,
+		"data":    amendment,
 	})
 }
 
@@ -479,6 +1240,194 @@ func createVoter(contract *client.Contract, c *gin.Context) {
 	})
 }
 
+// @Summary Import Voters
+// @Description Register voters for an election from a CSV of student ID, email and faculty.
+// @Description Voters are registered in batches, re-uploading the same file resumes an interrupted import
+// @Tags Voter
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param electionID formData string true "Election ID"
+// @Param file formData file true "CSV file"
+// @Success 200 {string} string "Voters imported"
+// @Router /voter/import [post]
+func importVoters(contract *client.Contract, c *gin.Context) {
+	electionID := c.PostForm("electionID")
+	if electionID == "" {
+		electionID = c.Query("electionID")
+	}
+	if electionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "electionID is required"})
+		return
+	}
+
+	// accept the CSV as a multipart file or as the raw request body
+	var body io.Reader = c.Request.Body
+	file, err := c.FormFile("file")
+	if err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	rows, results, err := parseVoterCSV(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// an interrupted import is resumed by uploading the same file again,
+	// voters registered by the earlier run are reported as exists
+	resumeFromRow := 0
+	var importErr error
+	for start := 0; start < len(rows); start += voterImportBatchSize {
+		end := start + voterImportBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batch := rows[start:end]
+		batchAsBytes, _ := json.Marshal(batch)
+
+		result, err := contract.SubmitTransaction("createVoters", electionID, string(batchAsBytes))
+		if err != nil {
+			resumeFromRow = batch[0].Row
+			importErr = err
+			break
+		}
+
+		var batchResults []voterRowResult
+		json.Unmarshal(result, &batchResults)
+		for i, batchResult := range batchResults {
+			batchResult.Row = batch[i].Row
+			results = append(results, batchResult)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Row < results[j].Row
+	})
+	summary := map[string]int{"created": 0, "exists": 0, "duplicate": 0, "invalid": 0}
+	for _, result := range results {
+		summary[result.Status]++
+	}
+
+	if importErr != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":         importErr.Error(),
+			"message":       "Import interrupted. Upload the same file again to resume.",
+			"resumeFromRow": resumeFromRow,
+			"summary":       summary,
+			"data":          results,
+			"status":        http.StatusBadGateway,
+		})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Voters imported. Txn committed successfully.",
+		"summary": summary,
+		"data":    results,
+		"status":  http.StatusOK,
+	})
+}
+
+// read a voter import CSV
+// returns the valid rows to register and results for rows that failed
+// validation or repeat an earlier row, a header row is skipped
+func parseVoterCSV(body io.Reader) ([]voterRow, []voterRowResult, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows := []voterRow{}
+	results := []voterRowResult{}
+	seen := map[string]int{}
+	line := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CSV at row %d: %w", line, err)
+		}
+		if line == 1 && len(record) > 0 && strings.EqualFold(strings.ReplaceAll(strings.TrimSpace(record[0]), "_", ""), "studentid") {
+			continue
+		}
+
+		result := voterRowResult{Row: line}
+		if len(record) != 3 {
+			result.Status = "invalid"
+			result.Error = "expected 3 columns: student ID, email, faculty"
+			results = append(results, result)
+			continue
+		}
+		row := voterRow{
+			Row:       line,
+			StudentID: strings.TrimSpace(record[0]),
+			Email:     strings.TrimSpace(record[1]),
+			Faculty:   strings.TrimSpace(record[2]),
+		}
+		result.StudentID = row.StudentID
+		if row.StudentID == "" {
+			result.Status = "invalid"
+			result.Error = "student ID is required"
+		} else if _, err := mail.ParseAddress(row.Email); err != nil {
+			result.Status = "invalid"
+			result.Error = "invalid email: " + row.Email
+		} else if row.Faculty == "" {
+			result.Status = "invalid"
+			result.Error = "faculty is required"
+		} else if firstRow, ok := seen[row.StudentID]; ok {
+			result.Status = "duplicate"
+			result.Error = fmt.Sprintf("student ID already on row %d", firstRow)
+		}
+		if result.Status != "" {
+			results = append(results, result)
+			continue
+		}
+		seen[row.StudentID] = line
+		rows = append(rows, row)
+	}
+	return rows, results, nil
+}
+
+// @Summary Set Voter Weight
+// @Description Assign a voter's vote weight for a weighted election before it opens. The gateway identity must carry the committee role
+// @Tags Voter
+// @Accept  json
+// @Produce  json
+// @Body  {object} voterID, electionID, weight
+// @Success 200 {string} string "Voter weight set"
+// @Router /voter/weight [post]
+func setVoterWeight(contract *client.Contract, c *gin.Context) {
+
+	var voterWeight voterWeight
+	if err := c.ShouldBindJSON(&voterWeight); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := contract.SubmitTransaction("setVoterWeight", voterWeight.VoterID, voterWeight.ElectionID, strconv.Itoa(voterWeight.Weight))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Voter weight set. Txn committed successfully.",
+		"status":  http.StatusOK,
+	})
+}
+
 // @Summary vote
 // @Description vote for a candidate
 // @Tags Ballot
@@ -509,7 +1458,8 @@ func castVote(contract *client.Contract, c *gin.Context) {
 }
 
 // @Summary vote v2
-// @Description vote for a candidate
+// @Description vote for a candidate, or pass "abstain" or "blank" as candidateID.
+// @Description "reopen" votes to re-open nominations when only one candidate stands
 // @Tags Ballot
 // @Accept  json
 // @Produce  json
@@ -528,15 +1478,11 @@ func castVoteV2(contract *client.Contract, c *gin.Context, wg *sync.WaitGroup, m
 		return
 	}
 
-	doServer := goDotEnvVariable("DB_STRING")
-
-	opt, err := pg.ParseURL(doServer)
-
+	db, err := connectAuthDB()
 	if err != nil {
 		panic(err)
 	}
-
-	db := pg.Connect(opt)
+	defer db.Close()
 
 	// connect pgsql
 	// db := pg.Connect(&pg.Options{
@@ -546,26 +1492,7 @@ func castVoteV2(contract *client.Contract, c *gin.Context, wg *sync.WaitGroup, m
 	// 	Database: "fabric-voting-users",
 	// })
 
-	// if connection fails
-	if db == nil {
-		fmt.Println("Error connecting to database")
-	}
-
-	// get user with email and salted password
-	type user struct {
-		tableName struct{} `pg:"auth.users"`
-		Email     string
-		Password  string
-		Id        int
-		Faculty   string
-	}
-
-	// unsalt password using pgcrypto
-	var u user
-	err = db.Model(&u).
-		Where("email = ?", voteV2.Email).
-		Where("password = crypt(?, password)", voteV2.Password).
-		Select()
+	u, err := authenticateUser(db, voteV2.Email, voteV2.Password)
 
 	responseChan, ok := c.Get("responseChan")
 	if !ok {
@@ -581,10 +1508,11 @@ func castVoteV2(contract *client.Contract, c *gin.Context, wg *sync.WaitGroup, m
 	}
 
 	if err != nil {
-		// return error message wrong email or password
+		// wrong email or password, or the auth database failed
+		status, message := authErrorResponse(err)
 		response := &Response{
-			Message: "Wrong email or password",
-			Status:  http.StatusBadRequest,
+			Message: message,
+			Status:  status,
 		}
 
 		// Send the response back through the channel
@@ -597,7 +1525,9 @@ func castVoteV2(contract *client.Contract, c *gin.Context, wg *sync.WaitGroup, m
 	fmt.Println(voteV2.CandidateID, voteV2.ElectionID, userID)
 
 	// run chaincode ballot v2 here
-	_, err = contract.SubmitTransaction("voteV2", userID, voteV2.CandidateID, voteV2.ElectionID)
+	// the chaincode checks the voter's faculty, year and programme
+	// against the election's eligibility rule
+	_, err = contract.SubmitTransaction("voteV2", userID, voteV2.CandidateID, voteV2.ElectionID, attributesOf(u))
 
 	if err != nil {
 		response := &Response{
@@ -631,3 +1561,98 @@ func castVoteV2(contract *client.Contract, c *gin.Context, wg *sync.WaitGroup, m
 	responseChan.(chan *Response) <- response
 
 }
+
+// @Summary delegate vote
+// @Description delegate your ballot for an election to another student before it opens
+// @Tags Ballot
+// @Accept  json
+// @Produce  json
+// @Body  {object} email, password, delegateEmail, electionID
+// @Success 200 {string} string "Vote delegated"
+// @Router /ballot/delegate [post]
+func delegateVote(contract *client.Contract, c *gin.Context) {
+	var delegation delegation
+	if err := c.ShouldBindJSON(&delegation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, err := connectAuthDB()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer db.Close()
+
+	u, err := authenticateUser(db, delegation.Email, delegation.Password)
+	if err != nil {
+		status, message := authErrorResponse(err)
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	delegate, err := findUserByEmail(db, delegation.DelegateEmail)
+	if err == errUserNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Delegate not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = contract.SubmitTransaction("delegateVote", strconv.Itoa(u.Id), strconv.Itoa(delegate.Id), delegation.ElectionID, attributesOf(u), attributesOf(delegate))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Vote delegated. Txn committed successfully.",
+		"status":  http.StatusOK,
+	})
+}
+
+// @Summary revoke delegation
+// @Description revoke a delegated ballot, allowed until the delegate has voted
+// @Tags Ballot
+// @Accept  json
+// @Produce  json
+// @Body  {object} email, password, electionID
+// @Success 200 {string} string "Delegation revoked"
+// @Router /ballot/delegate/revoke [post]
+func revokeDelegation(contract *client.Contract, c *gin.Context) {
+	var delegation delegation
+	if err := c.ShouldBindJSON(&delegation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, err := connectAuthDB()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer db.Close()
+
+	u, err := authenticateUser(db, delegation.Email, delegation.Password)
+	if err != nil {
+		status, message := authErrorResponse(err)
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	_, err = contract.SubmitTransaction("revokeDelegation", strconv.Itoa(u.Id), delegation.ElectionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Delegation revoked. Txn committed successfully.",
+		"status":  http.StatusOK,
+	})
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// reason recorded on the ledger with a suspension or resumption
+type suspensionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// @Summary Suspend Election
+// @Description Stop accepting ballots for an election, eg when the auth database or a polling station is compromised. The gateway identity must carry the committee role
+// @Tags Election
+// @Accept  json
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Body  {object} reason
+// @Success 200 {string} string "Election suspended"
+// @Router /election/{electionID}/suspend [post]
+func suspendElection(contract *client.Contract, c *gin.Context) {
+	setElectionSuspension(contract, c, "suspendElection", "Election suspended.")
+}
+
+// @Summary Resume Election
+// @Description Accept ballots again for a suspended election.
+// @Description Elections created with extendOnResume have their end date pushed back by the voting time lost
+// @Description The gateway identity must carry the committee role
+// @Tags Election
+// @Accept  json
+// @Produce  json
+// @Param electionID path string true "Election ID"
+// @Body  {object} reason
+// @Success 200 {string} string "Election resumed"
+// @Router /election/{electionID}/resume [post]
+func resumeElection(contract *client.Contract, c *gin.Context) {
+	setElectionSuspension(contract, c, "resumeElection", "Election resumed.")
+}
+
+func setElectionSuspension(contract *client.Contract, c *gin.Context, transaction string, message string) {
+	electionID := c.Param("electionID")
+
+	var request suspensionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := contract.SubmitTransaction(transaction, electionID, request.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("*** Transaction committed successfully\n")
+
+	var response interface{}
+	json.Unmarshal(result, &response)
+	c.JSON(http.StatusOK, gin.H{
+		"message": message + " Txn committed successfully.",
+		"data":    response,
+		"status":  http.StatusOK,
+	})
+}
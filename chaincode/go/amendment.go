@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// reason codes an amendment to a running election must give
+var amendmentReasons = map[string]bool{
+	"correction":        true,
+	"technicalIssue":    true,
+	"extensionRequest":  true,
+	"committeeDecision": true,
+	"disruption":        true,
+	"other":             true,
+}
+
+// amendment status
+// an amendment to a running election that is not an extension waits for
+// approval by two distinct committee identities
+const (
+	amendmentApplied = "applied"
+	amendmentPending = "pending"
+)
+
+// approvals needed before a restricted amendment is applied
+const amendmentApprovals = 2
+
+// amendments are kept per election, see electionRecordKey
+const amendmentObjectType = "amendment"
+
+// a change to an election's name or dates
+// every change is recorded, AmendmentID is the transaction that proposed it
+type amendment struct {
+	AmendmentID string              `json:"amendmentID"`
+	ElectionID  string              `json:"electionID"`
+	Target      string              `json:"target"`
+	From        string              `json:"from"`
+	To          string              `json:"to"`
+	ReasonCode  string              `json:"reasonCode,omitempty"`
+	Reason      string              `json:"reason,omitempty"`
+	ProposedBy  string              `json:"proposedBy"`
+	ProposedAt  string              `json:"proposedAt"`
+	Approvals   []amendmentApproval `json:"approvals"`
+	Status      string              `json:"status"`
+	AppliedAt   string              `json:"appliedAt,omitempty"`
+}
+
+type amendmentApproval struct {
+	Identity   string `json:"identity"`
+	ApprovedAt string `json:"approvedAt"`
+}
+
+// amend an election's name, start date or end date
+// once the election has opened a reason code is required and only the name and
+// later end dates are applied straight away, anything else waits for approval
+// args: electionID, target (name|startDate|endDate), value, [reasonCode, reason]
+func (t *VotingChaincode) updateElection(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 && len(args) != 5 {
+		return shim.Error("Incorrect number of arguments. Expecting 3 or 5")
+	}
+	electionId := args[0]
+	target := args[1]
+	value := args[2]
+	reasonCode, reason := "", ""
+	if len(args) == 5 {
+		reasonCode = args[3]
+		reason = strings.TrimSpace(args[4])
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.Status == electionClosed {
+		return shim.Error("Election is closed")
+	}
+	from, err := electionField(election, target)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if reasonCode != "" && !amendmentReasons[reasonCode] {
+		return shim.Error("Invalid reason code: " + reasonCode)
+	}
+
+	started, err := electionHasStarted(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	restricted := false
+	if started {
+		if reasonCode == "" {
+			return shim.Error("Amending a running election requires a reason code")
+		}
+		restricted, err = isRestrictedAmendment(election, target, value)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	proposedBy, err := submitterIdentity(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	proposedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	a := &amendment{
+		AmendmentID: stub.GetTxID(),
+		ElectionID:  electionId,
+		Target:      target,
+		From:        from,
+		To:          value,
+		ReasonCode:  reasonCode,
+		Reason:      reason,
+		ProposedBy:  proposedBy,
+		ProposedAt:  proposedAt,
+		Approvals:   []amendmentApproval{},
+		Status:      amendmentPending,
+	}
+	if !restricted {
+		err = applyAmendment(stub, election, a)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	amendmentAsBytes, err := putAmendment(stub, a)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("election amendment %s %s %s\n", a.Status, electionId, target)
+	return shim.Success(amendmentAsBytes)
+}
+
+// approve a pending amendment, only committee identities may approve
+// the amendment is applied once two distinct identities have approved it
+// args: electionID, amendmentID
+func (t *VotingChaincode) approveAmendment(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	electionId := args[0]
+	amendmentId := args[1]
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	identity, err := submitterIdentity(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	a, err := getAmendment(stub, electionId, amendmentId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if a.Status != amendmentPending {
+		return shim.Error("Amendment is " + a.Status)
+	}
+	for _, approval := range a.Approvals {
+		if approval.Identity == identity {
+			return shim.Error("Amendment has already been approved by " + identity)
+		}
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.Status == electionClosed {
+		return shim.Error("Election is closed")
+	}
+	// the election may have changed since the amendment was proposed
+	current, err := electionField(election, a.Target)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if current != a.From {
+		return shim.Error("Election " + a.Target + " has changed since the amendment was proposed")
+	}
+
+	approvedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	a.Approvals = append(a.Approvals, amendmentApproval{Identity: identity, ApprovedAt: approvedAt})
+	if len(a.Approvals) >= amendmentApprovals {
+		err = applyAmendment(stub, election, a)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	amendmentAsBytes, err := putAmendment(stub, a)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("election amendment approved %s %s %d/%d\n", electionId, amendmentId, len(a.Approvals), amendmentApprovals)
+	return shim.Success(amendmentAsBytes)
+}
+
+// get every amendment recorded for an election
+// args: electionID
+func (t *VotingChaincode) getAmendments(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(amendmentObjectType, []string{args[0]})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer resultsIterator.Close()
+
+	amendments := []amendment{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		a := amendment{}
+		json.Unmarshal(queryResponse.Value, &a)
+		amendments = append(amendments, a)
+	}
+	amendmentsAsBytes, _ := json.Marshal(amendments)
+	return shim.Success(amendmentsAsBytes)
+}
+
+// check whether an amendment to a running election needs approval
+// renaming and extending the end date do not, shortening the voting window does
+func isRestrictedAmendment(e *election, target string, value string) (bool, error) {
+	switch target {
+	case "name":
+		return false, nil
+	case "endDate":
+		currentEndDate, err := time.Parse(time.RFC3339, e.EndDate)
+		if err != nil {
+			return false, fmt.Errorf("Failed to parse election end date: %s", e.EndDate)
+		}
+		endDate, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return false, fmt.Errorf("Invalid end date: %s", value)
+		}
+		return !endDate.After(currentEndDate), nil
+	}
+	return true, nil
+}
+
+// apply an amendment to its election
+func applyAmendment(stub shim.ChaincodeStubInterface, e *election, a *amendment) error {
+	switch a.Target {
+	case "name":
+		if strings.TrimSpace(a.To) == "" {
+			return fmt.Errorf("Election name cannot be empty")
+		}
+		e.ElectionName = a.To
+	case "startDate", "endDate":
+		if _, err := time.Parse(time.RFC3339, a.To); err != nil {
+			return fmt.Errorf("Invalid %s: %s", a.Target, a.To)
+		}
+		if a.Target == "startDate" {
+			e.StartDate = a.To
+		} else {
+			e.EndDate = a.To
+		}
+		if e.StartDate > e.EndDate {
+			return fmt.Errorf("Invalid election dates")
+		}
+	}
+
+	appliedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	e.UpdatedAt = &appliedAt
+	_, err = putElection(stub, e)
+	if err != nil {
+		return err
+	}
+	a.Status = amendmentApplied
+	a.AppliedAt = appliedAt
+	return nil
+}
+
+// current value of an amendable election field
+func electionField(e *election, target string) (string, error) {
+	switch target {
+	case "name":
+		return e.ElectionName, nil
+	case "startDate":
+		return e.StartDate, nil
+	case "endDate":
+		return e.EndDate, nil
+	}
+	return "", fmt.Errorf("Invalid target")
+}
+
+func getAmendment(stub shim.ChaincodeStubInterface, electionId string, amendmentId string) (*amendment, error) {
+	key, err := electionRecordKey(stub, amendmentObjectType, electionId, amendmentId)
+	if err != nil {
+		return nil, err
+	}
+	amendmentAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get amendment: %s", amendmentId)
+	}
+	if amendmentAsBytes == nil {
+		return nil, fmt.Errorf("Amendment does not exist: %s", amendmentId)
+	}
+	a := &amendment{}
+	json.Unmarshal(amendmentAsBytes, a)
+	return a, nil
+}
+
+func putAmendment(stub shim.ChaincodeStubInterface, a *amendment) ([]byte, error) {
+	key, err := electionRecordKey(stub, amendmentObjectType, a.ElectionID, a.AmendmentID)
+	if err != nil {
+		return nil, err
+	}
+	amendmentAsBytes, _ := json.Marshal(a)
+	return amendmentAsBytes, stub.PutState(key, amendmentAsBytes)
+}
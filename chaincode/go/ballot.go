@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// an election with all its positions and candidates, applied in one transaction
+// each position becomes its own election so it is voted on and tallied separately
+// election IDs are assigned by the REST API so every endorsing peer writes the same keys
+type ballotDefinition struct {
+	BallotID     string               `json:"ballotID"`
+	ElectionName string               `json:"electionName"`
+	StartDate    string               `json:"startDate"`
+	EndDate      string               `json:"endDate"`
+	Options      electionOptions      `json:"options"`
+	Positions    []positionDefinition `json:"positions"`
+}
+
+type positionDefinition struct {
+	ElectionID string `json:"electionID"`
+	Position   string `json:"position"`
+	// overrides the ballot's eligibility rule for this position
+	Eligibility *eligibilityRule `json:"eligibility,omitempty"`
+	// faculty ID, restricts this position to one constituency
+	Constituency string                `json:"constituency,omitempty"`
+	Candidates   []candidateDefinition `json:"candidates"`
+}
+
+type candidateDefinition struct {
+	Name      string `json:"name"`
+	StudentID string `json:"studentID"`
+	Faculty   string `json:"faculty"`
+	Party     string `json:"party"`
+	PartyID   string `json:"partyID"`
+	Avatar    string `json:"avatar"`
+}
+
+// a change made, or that would be made, by a ballot definition
+// action is createElection, createCandidate or enterCandidate
+type ballotChange struct {
+	Action     string `json:"action"`
+	ElectionID string `json:"electionID"`
+	Position   string `json:"position,omitempty"`
+	StudentID  string `json:"studentID,omitempty"`
+}
+
+// apply a ballot definition, creating its elections and candidates
+// nothing is written unless the whole definition is valid
+// evaluating rather than submitting the transaction gives a dry run
+// args: JSON ballot definition, createdAt
+func (t *VotingChaincode) applyBallotDefinition(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	createdAt := args[1]
+
+	definition := ballotDefinition{}
+	err := json.Unmarshal([]byte(args[0]), &definition)
+	if err != nil {
+		return shim.Error("Invalid ballot definition: " + err.Error())
+	}
+	if strings.TrimSpace(definition.ElectionName) == "" {
+		return shim.Error("Ballot definition requires an election name")
+	}
+	if len(definition.Positions) == 0 {
+		return shim.Error("Ballot definition requires at least one position")
+	}
+
+	nominatedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	changes := []ballotChange{}
+	elections := []*election{}
+	// candidates entering more than one position are updated in memory
+	candidates := map[string]*candidate{}
+	candidateOrder := []string{}
+	positions := map[string]bool{}
+	// position each candidate stands for, a candidate cannot stand for two
+	standingFor := map[string]string{}
+
+	for _, position := range definition.Positions {
+		if position.ElectionID == "" || strings.TrimSpace(position.Position) == "" {
+			return shim.Error("Every position requires an electionID and a name")
+		}
+		if positions[position.Position] {
+			return shim.Error("Position is defined twice: " + position.Position)
+		}
+		positions[position.Position] = true
+		for _, e := range elections {
+			if e.ElectionID == position.ElectionID {
+				return shim.Error("Election ID is used twice: " + position.ElectionID)
+			}
+		}
+
+		options := definition.Options
+		if position.Eligibility != nil {
+			options.Eligibility = position.Eligibility
+		}
+		if position.Constituency != "" {
+			options.Constituency = position.Constituency
+		}
+		election, err := newElection(stub, position.ElectionID, definition.ElectionName, definition.StartDate, definition.EndDate, createdAt, options)
+		if err != nil {
+			return shim.Error(position.Position + ": " + err.Error())
+		}
+		election.Position = position.Position
+		election.BallotID = definition.BallotID
+		elections = append(elections, election)
+		changes = append(changes, ballotChange{Action: "createElection", ElectionID: election.ElectionID, Position: position.Position})
+
+		// candidates of a nominated election must come through the nomination workflow
+		if election.Nominations && len(position.Candidates) > 0 {
+			return shim.Error(position.Position + ": candidates for this election must be nominated")
+		}
+
+		entered := map[string]bool{}
+		for _, c := range position.Candidates {
+			if strings.TrimSpace(c.StudentID) == "" || strings.TrimSpace(c.Name) == "" {
+				return shim.Error(position.Position + ": every candidate requires a name and studentID")
+			}
+			if entered[c.StudentID] {
+				return shim.Error(position.Position + ": candidate is listed twice: " + c.StudentID)
+			}
+			entered[c.StudentID] = true
+			if err := checkAvatarUnset(c.Avatar); err != nil {
+				return shim.Error(position.Position + ": " + err.Error())
+			}
+
+			studentId := "candidate." + c.StudentID
+			if other, ok := standingFor[studentId]; ok {
+				return errorResponse(&conflictError{position.Position + ": candidate is already standing for " + other + ": " + studentId})
+			}
+			standingFor[studentId] = position.Position
+			if err := checkCandidateEntry(stub, studentId, election); err != nil {
+				return errorResponse(err)
+			}
+			existing, ok := candidates[studentId]
+			action := "enterCandidate"
+			if !ok {
+				candidateAsBytes, err := stub.GetState(studentId)
+				if err != nil {
+					return shim.Error("Failed to get candidate: " + studentId)
+				}
+				existing = &candidate{}
+				if candidateAsBytes != nil {
+					json.Unmarshal(candidateAsBytes, existing)
+				} else {
+					existing = &candidate{
+						StudentID: studentId,
+						Name:      c.Name,
+						Faculty:   c.Faculty,
+						Avatar:    c.Avatar,
+						Elections: []electionInfo{},
+					}
+					existing.PartyID, existing.Party, err = resolveParty(stub, c.PartyID, c.Party)
+					if err != nil {
+						return shim.Error(position.Position + ": " + err.Error())
+					}
+					action = "createCandidate"
+				}
+				candidates[studentId] = existing
+				candidateOrder = append(candidateOrder, studentId)
+			}
+			info := electionInfo{ElectionID: election.ElectionID, Votes: 0, NominatedAt: nominatedAt}
+			existing.Elections = append(existing.Elections, info)
+			changes = append(changes, ballotChange{Action: action, ElectionID: election.ElectionID, Position: position.Position, StudentID: studentId})
+		}
+	}
+
+	for _, e := range elections {
+		_, err = putElection(stub, e)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	for _, studentId := range candidateOrder {
+		candidateAsBytes, _ := json.Marshal(candidates[studentId])
+		err := stub.PutState(studentId, candidateAsBytes)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	fmt.Printf("ballot definition applied %s: %d changes\n", definition.BallotID, len(changes))
+	changesAsBytes, _ := json.Marshal(changes)
+	return shim.Success(changesAsBytes)
+}
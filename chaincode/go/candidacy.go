@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// candidate status in an election
+// the entry of a withdrawn or disqualified candidate is kept so the votes
+// already cast for them stay on the ledger, but they no longer take part in
+// the tally: new ballots for them are rejected and ballots already cast are void
+const (
+	candidateStanding     = "standing"
+	candidateWithdrawn    = "withdrawn"
+	candidateDisqualified = "disqualified"
+)
+
+// status returned for requests that conflict with the ledger, eg a candidate
+// entered twice, so the REST API can tell them apart from invalid requests
+const statusConflict = 409
+
+type conflictError struct {
+	message string
+}
+
+func (e *conflictError) Error() string {
+	return e.message
+}
+
+// status returned when the record a request names does not exist
+const statusNotFound = 404
+
+type notFoundError struct {
+	message string
+}
+
+func (e *notFoundError) Error() string {
+	return e.message
+}
+
+// turn an error into a chaincode response, conflicts and missing records keep their status
+func errorResponse(err error) pb.Response {
+	switch err.(type) {
+	case *conflictError:
+		return pb.Response{Status: statusConflict, Message: err.Error()}
+	case *notFoundError:
+		return pb.Response{Status: statusNotFound, Message: err.Error()}
+	}
+	return shim.Error(err.Error())
+}
+
+// check that a candidate can be entered into an election
+// candidates are entered once per election, before it opens, and cannot
+// stand for two positions of the same ballot definition
+func checkCandidateEntry(stub shim.ChaincodeStubInterface, studentId string, e *election) error {
+	if e.Status == electionClosed {
+		return fmt.Errorf("Election is closed")
+	}
+	started, err := electionHasStarted(stub, e)
+	if err != nil {
+		return err
+	}
+	if started {
+		return fmt.Errorf("Candidates can only be entered before the election opens")
+	}
+
+	candidateAsBytes, err := stub.GetState(studentId)
+	if err != nil {
+		return fmt.Errorf("Failed to get candidate: %s", studentId)
+	}
+	if candidateAsBytes == nil {
+		return nil
+	}
+	candidate := candidate{}
+	json.Unmarshal(candidateAsBytes, &candidate)
+	for _, info := range candidate.Elections {
+		if info.ElectionID == e.ElectionID {
+			return &conflictError{fmt.Sprintf("Candidate is already entered in election: %s", e.ElectionID)}
+		}
+		// a withdrawn or disqualified candidate is free to stand for another position
+		if e.BallotID == "" || info.Status != "" {
+			continue
+		}
+		other, err := readElection(stub, info.ElectionID)
+		if err != nil {
+			return err
+		}
+		if other.Status != electionCancelled && other.BallotID == e.BallotID {
+			return &conflictError{fmt.Sprintf("Candidate is already standing for %s on the same ballot", other.Position)}
+		}
+	}
+	return nil
+}
+
+// withdraw a candidate from an election before it opens
+// submitted by the candidate or by a committee member on their behalf
+// args: studentID, electionID, [reason]
+func (t *VotingChaincode) withdrawCandidate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 && len(args) != 3 {
+		return shim.Error("Incorrect number of arguments. Expecting 2 or 3")
+	}
+	reason := ""
+	if len(args) == 3 {
+		reason = strings.TrimSpace(args[2])
+	}
+
+	c, err := getCandidate(stub, "candidate."+args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = assertCandidateOrCommittee(stub, c)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, args[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	started, err := electionHasStarted(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if started {
+		return shim.Error("Candidates can only withdraw before the election opens")
+	}
+
+	return setCandidateStatus(stub, "candidate."+args[0], election.ElectionID, candidateWithdrawn, reason)
+}
+
+// disqualify a candidate, allowed at any time and only by committee members
+// args: studentID, electionID, reason
+func (t *VotingChaincode) disqualifyCandidate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return shim.Error("Incorrect number of arguments. Expecting 3")
+	}
+	reason := strings.TrimSpace(args[2])
+	if reason == "" {
+		return shim.Error("A disqualification requires a reason")
+	}
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, args[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return setCandidateStatus(stub, "candidate."+args[0], election.ElectionID, candidateDisqualified, reason)
+}
+
+func setCandidateStatus(stub shim.ChaincodeStubInterface, studentId string, electionId string, status string, reason string) pb.Response {
+	candidateAsBytes, err := stub.GetState(studentId)
+	if err != nil {
+		return shim.Error("Failed to get candidate: " + studentId)
+	}
+	if candidateAsBytes == nil {
+		return shim.Error("Candidate does not exist: " + studentId)
+	}
+	candidate := candidate{}
+	json.Unmarshal(candidateAsBytes, &candidate)
+
+	statusAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	found := false
+	for i := range candidate.Elections {
+		info := &candidate.Elections[i]
+		if info.ElectionID != electionId {
+			continue
+		}
+		found = true
+		// a withdrawn candidate can still be disqualified, eg for conduct during the campaign
+		if info.Status == status || info.Status == candidateDisqualified {
+			return shim.Error("Candidate is already " + info.Status)
+		}
+		info.Status = status
+		info.StatusReason = reason
+		info.StatusAt = statusAt
+	}
+	if !found {
+		return shim.Error("Candidate is not contesting election: " + electionId)
+	}
+
+	candidateAsBytes, _ = json.Marshal(candidate)
+	err = stub.PutState(studentId, candidateAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("candidate %s %s %s\n", status, studentId, electionId)
+	return shim.Success(candidateAsBytes)
+}
+
+// status of a candidate's entry in an election
+func candidateStatus(info electionInfo) string {
+	if info.Status == "" {
+		return candidateStanding
+	}
+	return info.Status
+}
+
+// split an election's candidates into those standing and those withdrawn or disqualified
+func standingCandidates(candidates []candidate, electionId string) ([]candidate, []candidate) {
+	standing := []candidate{}
+	excluded := []candidate{}
+	for _, c := range candidates {
+		isStanding := true
+		for _, info := range c.Elections {
+			if info.ElectionID == electionId && info.Status != "" {
+				isStanding = false
+			}
+		}
+		if isStanding {
+			standing = append(standing, c)
+		} else {
+			excluded = append(excluded, c)
+		}
+	}
+	return standing, excluded
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// organizations whose signatures certify election results
+// and how many of them must sign, Org1 and Org2 of test-network by default
+var (
+	certificationOrgs      = []string{"Org1MSP", "Org2MSP"}
+	certificationThreshold = 2
+)
+
+// certification signatures are kept per election, see electionRecordKey
+const certificationObjectType = "certification"
+
+// canonical results document signed by the certifying organizations
+// only what decides the outcome is included, so candidate profile changes
+// after the election do not invalidate the signatures
+type resultsDocument struct {
+	ElectionID           string              `json:"electionID"`
+	ElectionName         string              `json:"electionName"`
+	StartDate            string              `json:"startDate"`
+	EndDate              string              `json:"endDate"`
+	Type                 string              `json:"type"`
+	Candidates           []resultsCandidate  `json:"candidates"`
+	Excluded             []resultsCandidate  `json:"excluded"`
+	Winner               string              `json:"winner"`
+	TotalVotes           int                 `json:"totalVotes"`
+	Abstain              int                 `json:"abstain"`
+	Blank                int                 `json:"blank"`
+	Void                 int                 `json:"void"`
+	TotalBallots         int                 `json:"totalBallots"`
+	TotalWeightedVotes   int                 `json:"totalWeightedVotes"`
+	WeightedAbstain      int                 `json:"weightedAbstain"`
+	WeightedBlank        int                 `json:"weightedBlank"`
+	WeightedVoid         int                 `json:"weightedVoid"`
+	TotalWeightedBallots int                 `json:"totalWeightedBallots"`
+	Confirmation         *confirmationResult `json:"confirmation,omitempty"`
+	Tie                  *tieBreakResult     `json:"tie,omitempty"`
+	RunoffRequired       bool                `json:"runoffRequired"`
+	RunoffElectionID     string              `json:"runoffElectionID,omitempty"`
+}
+
+type resultsCandidate struct {
+	StudentID string `json:"studentID"`
+	Votes     int    `json:"votes"`
+}
+
+// results document with the hash each organization signs
+type signedResultsDocument struct {
+	Document resultsDocument `json:"document"`
+	Hash     string          `json:"hash"`
+}
+
+// an organization's signature over a results document hash
+// the submitter's certificate is kept so the signature can be checked again later
+type certificationSignature struct {
+	ElectionID  string `json:"electionID"`
+	MSPID       string `json:"mspID"`
+	Identity    string `json:"identity"`
+	Certificate string `json:"certificate"`
+	ResultsHash string `json:"resultsHash"`
+	Signature   string `json:"signature"`
+	SignedAt    string `json:"signedAt"`
+	// set when the signature is checked against the current results document
+	Verified bool `json:"verified"`
+}
+
+type certification struct {
+	ElectionID  string                   `json:"electionID"`
+	ResultsHash string                   `json:"resultsHash"`
+	Orgs        []string                 `json:"orgs"`
+	Threshold   int                      `json:"threshold"`
+	Signatures  []certificationSignature `json:"signatures"`
+	Certified   bool                     `json:"certified"`
+	CertifiedAt string                   `json:"certifiedAt,omitempty"`
+}
+
+// get the canonical results document of a closed election and its hash
+// args: electionID
+func (t *VotingChaincode) getResultsDocument(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	election, err := getElection(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.Status != electionClosed {
+		return shim.Error("Election is not closed")
+	}
+	document, hash, err := electionResultsDocument(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	documentAsBytes, _ := json.Marshal(signedResultsDocument{Document: *document, Hash: hash})
+	return shim.Success(documentAsBytes)
+}
+
+// sign the results of a closed election on behalf of the submitter's organization
+// the signature is over the sha256 of the results document and must verify
+// against the submitter's certificate
+// the election is certified once the threshold of organizations have signed
+// args: electionID, resultsHash, base64 signature
+func (t *VotingChaincode) certifyResults(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return shim.Error("Incorrect number of arguments. Expecting 3")
+	}
+	electionId := args[0]
+	resultsHash := args[1]
+	signature := args[2]
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.Status != electionClosed {
+		return shim.Error("Election is not closed")
+	}
+
+	mspId, err := cid.GetMSPID(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !isCertificationOrg(mspId) {
+		return shim.Error("Organization does not certify results: " + mspId)
+	}
+	cert, err := cid.GetX509Certificate(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if cert == nil {
+		return shim.Error("Certifying identity has no X.509 certificate")
+	}
+
+	_, hash, err := electionResultsDocument(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if resultsHash != hash {
+		return shim.Error("Results hash does not match the election results: " + hash)
+	}
+
+	existing, err := getCertificationSignature(stub, electionId, mspId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if existing != nil && existing.ResultsHash == hash {
+		return errorResponse(&conflictError{"Results have already been certified by " + mspId})
+	}
+
+	identity, err := submitterIdentity(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	signedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	s := &certificationSignature{
+		ElectionID:  electionId,
+		MSPID:       mspId,
+		Identity:    identity,
+		Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+		ResultsHash: hash,
+		Signature:   signature,
+		SignedAt:    signedAt,
+	}
+	if err := verifyCertificationSignature(s, hash); err != nil {
+		return shim.Error(err.Error())
+	}
+	key, err := electionRecordKey(stub, certificationObjectType, electionId, mspId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	signatureAsBytes, _ := json.Marshal(s)
+	err = stub.PutState(key, signatureAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// count this signature alongside the ones already stored
+	// for the other organizations
+	c, err := electionCertification(stub, election, hash)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	signatures := []certificationSignature{}
+	for _, other := range c.Signatures {
+		if other.MSPID != mspId {
+			signatures = append(signatures, other)
+		}
+	}
+	s.Verified = true
+	c.Signatures = append(signatures, *s)
+	if !election.Certified && verifiedSignatures(c) >= certificationThreshold {
+		election.Certified = true
+		election.CertifiedAt = signedAt
+		election.ResultsHash = hash
+		_, err = putElection(stub, election)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		fmt.Printf("election results certified %s %s\n", electionId, hash)
+	}
+	c.Certified = election.Certified
+	c.CertifiedAt = election.CertifiedAt
+
+	certificationAsBytes, _ := json.Marshal(c)
+	return shim.Success(certificationAsBytes)
+}
+
+// get an election's certification, every stored signature is checked again
+// against the current results document
+// args: electionID
+func (t *VotingChaincode) getCertification(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	election, err := getElection(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.Status != electionClosed {
+		return shim.Error("Election is not closed")
+	}
+	_, hash, err := electionResultsDocument(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	c, err := electionCertification(stub, election, hash)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	// a certified election whose results no longer match its signatures
+	// is reported as uncertified
+	c.Certified = election.Certified && election.ResultsHash == hash && verifiedSignatures(c) >= certificationThreshold
+	c.CertifiedAt = election.CertifiedAt
+	certificationAsBytes, _ := json.Marshal(c)
+	return shim.Success(certificationAsBytes)
+}
+
+// build the canonical results document of an election and its sha256 hex
+func electionResultsDocument(stub shim.ChaincodeStubInterface, e *election) (*resultsDocument, string, error) {
+	results, err := computeElectionResults(stub, e)
+	if err != nil {
+		return nil, "", err
+	}
+	document := &resultsDocument{
+		ElectionID:           e.ElectionID,
+		ElectionName:         e.ElectionName,
+		StartDate:            e.StartDate,
+		EndDate:              e.EndDate,
+		Type:                 e.Type,
+		Candidates:           resultsCandidates(results.Candidates, e),
+		Excluded:             resultsCandidates(results.Excluded, e),
+		Winner:               results.Winner.StudentID,
+		TotalVotes:           results.TotalVotes,
+		Abstain:              results.Abstain,
+		Blank:                results.Blank,
+		Void:                 results.Void,
+		TotalBallots:         results.TotalBallots,
+		TotalWeightedVotes:   results.TotalWeightedVotes,
+		WeightedAbstain:      results.WeightedAbstain,
+		WeightedBlank:        results.WeightedBlank,
+		WeightedVoid:         results.WeightedVoid,
+		TotalWeightedBallots: results.TotalWeightedBallots,
+		Confirmation:         results.Confirmation,
+		Tie:                  results.Tie,
+		RunoffRequired:       results.RunoffRequired,
+		RunoffElectionID:     e.RunoffElectionID,
+	}
+	documentAsBytes, _ := json.Marshal(document)
+	hash := sha256.Sum256(documentAsBytes)
+	return document, hex.EncodeToString(hash[:]), nil
+}
+
+func resultsCandidates(candidates []candidate, e *election) []resultsCandidate {
+	entries := []resultsCandidate{}
+	for _, c := range candidates {
+		entries = append(entries, resultsCandidate{StudentID: c.StudentID, Votes: candidateVotes(c, e)})
+	}
+	return entries
+}
+
+// stored signatures for an election, checked against the results hash
+func electionCertification(stub shim.ChaincodeStubInterface, e *election, hash string) (*certification, error) {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(certificationObjectType, []string{e.ElectionID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	c := &certification{
+		ElectionID:  e.ElectionID,
+		ResultsHash: hash,
+		Orgs:        certificationOrgs,
+		Threshold:   certificationThreshold,
+		Signatures:  []certificationSignature{},
+	}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		s := certificationSignature{}
+		json.Unmarshal(queryResponse.Value, &s)
+		s.Verified = isCertificationOrg(s.MSPID) && verifyCertificationSignature(&s, hash) == nil
+		c.Signatures = append(c.Signatures, s)
+	}
+	return c, nil
+}
+
+// number of configured organizations with a verified signature
+func verifiedSignatures(c *certification) int {
+	verified := map[string]bool{}
+	for _, s := range c.Signatures {
+		if s.Verified && isCertificationOrg(s.MSPID) {
+			verified[s.MSPID] = true
+		}
+	}
+	return len(verified)
+}
+
+// check a signature over a results hash against the signer's stored certificate
+func verifyCertificationSignature(s *certificationSignature, hash string) error {
+	if s.ResultsHash != hash {
+		return fmt.Errorf("Signature is for different results: %s", s.ResultsHash)
+	}
+	digest, err := hex.DecodeString(hash)
+	if err != nil {
+		return fmt.Errorf("Invalid results hash: %s", hash)
+	}
+	signature, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil {
+		return fmt.Errorf("Invalid signature encoding")
+	}
+	block, _ := pem.Decode([]byte(s.Certificate))
+	if block == nil {
+		return fmt.Errorf("Invalid certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("Invalid certificate: %s", err.Error())
+	}
+
+	verified := false
+	switch publicKey := cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		verified = ecdsa.VerifyASN1(publicKey, digest, signature)
+	case ed25519.PublicKey:
+		verified = ed25519.Verify(publicKey, digest, signature)
+	default:
+		return fmt.Errorf("Unsupported certificate key type")
+	}
+	if !verified {
+		return fmt.Errorf("Signature does not verify against the certificate of %s", s.Identity)
+	}
+	return nil
+}
+
+func getCertificationSignature(stub shim.ChaincodeStubInterface, electionId string, mspId string) (*certificationSignature, error) {
+	key, err := electionRecordKey(stub, certificationObjectType, electionId, mspId)
+	if err != nil {
+		return nil, err
+	}
+	signatureAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get certification: %s", electionId)
+	}
+	if signatureAsBytes == nil {
+		return nil, nil
+	}
+	s := &certificationSignature{}
+	json.Unmarshal(signatureAsBytes, s)
+	return s, nil
+}
+
+func isCertificationOrg(mspId string) bool {
+	for _, org := range certificationOrgs {
+		if org == mspId {
+			return true
+		}
+	}
+	return false
+}
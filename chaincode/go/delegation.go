@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// delegate a voter's ballot for an election to another voter
+// only allowed before the election opens
+// args: voterID, delegateID, electionID, [voterAttributes, delegateAttributes]
+// both voters must meet the election's eligibility rule
+func (t *VotingChaincode) delegateVote(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 && len(args) != 5 {
+		return shim.Error("Incorrect number of arguments. Expecting 3 or 5")
+	}
+	Id := args[0]
+	delegateId := args[1]
+	electionId := args[2]
+	voterAttributesAsJson, delegateAttributesAsJson := "", ""
+	if len(args) == 5 {
+		voterAttributesAsJson = args[3]
+		delegateAttributesAsJson = args[4]
+	}
+
+	if Id == delegateId {
+		return shim.Error("Voter cannot delegate to themselves")
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	started, err := electionHasStarted(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if started {
+		return shim.Error("Votes can only be delegated before the election opens")
+	}
+
+	voterAttributes, err := parseVoterAttributes(voterAttributesAsJson)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := checkVoterEligibility(stub, election, voterAttributes); err != nil {
+		return shim.Error(err.Error())
+	}
+	delegateAttributes, err := parseVoterAttributes(delegateAttributesAsJson)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := checkVoterEligibility(stub, election, delegateAttributes); err != nil {
+		return shim.Error("Delegate is not eligible: " + err.Error())
+	}
+
+	voter, err := getVoterV2(stub, Id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	delegate, err := getVoterV2(stub, delegateId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	voterEligibility := findOrAddEligibility(voter, electionId)
+	if voterEligibility.HasVoted {
+		return shim.Error("Voter has already voted")
+	}
+	if voterEligibility.DelegatedTo != "" {
+		return shim.Error("Voter has already delegated their vote to " + voterEligibility.DelegatedTo)
+	}
+	// delegations do not chain, a voter holding ballots for others must cast them
+	if len(voterEligibility.DelegatedFrom) > 0 {
+		return shim.Error("Voter is a delegate for other voters")
+	}
+	if election.Type == electionWeighted && voterEligibility.Weight <= 0 {
+		return shim.Error("Voter has no vote weight for this election")
+	}
+
+	delegateEligibility := findOrAddEligibility(delegate, electionId)
+	if delegateEligibility.HasVoted {
+		return shim.Error("Delegate has already voted")
+	}
+	if delegateEligibility.DelegatedTo != "" {
+		return shim.Error("Delegate has delegated their own vote")
+	}
+	if election.Type == electionWeighted && delegateEligibility.Weight <= 0 {
+		return shim.Error("Delegate has no vote weight for this election")
+	}
+
+	voterEligibility.DelegatedTo = delegateId
+	delegateEligibility.DelegatedFrom = append(delegateEligibility.DelegatedFrom, Id)
+
+	err = putVoterV2(stub, voter)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = putVoterV2(stub, delegate)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("vote delegated %s -> %s\n", Id, delegateId)
+	return shim.Success(nil)
+}
+
+// revoke a delegation, allowed until the delegate has voted
+// args: voterID, electionID
+func (t *VotingChaincode) revokeDelegation(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	Id := args[0]
+	electionId := args[1]
+
+	voter, err := getVoterV2(stub, Id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	voterEligibility := findEligibility(voter, electionId)
+	if voterEligibility == nil || voterEligibility.DelegatedTo == "" {
+		return shim.Error("Voter has not delegated their vote")
+	}
+	// the delegate's ballot marks the voter as voted
+	if voterEligibility.HasVoted {
+		return shim.Error("Delegate has already voted")
+	}
+
+	delegate, err := getVoterV2(stub, voterEligibility.DelegatedTo)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	delegateEligibility := findEligibility(delegate, electionId)
+	if delegateEligibility != nil {
+		if delegateEligibility.HasVoted {
+			return shim.Error("Delegate has already voted")
+		}
+		delegatedFrom := []string{}
+		for _, delegatorId := range delegateEligibility.DelegatedFrom {
+			if delegatorId != Id {
+				delegatedFrom = append(delegatedFrom, delegatorId)
+			}
+		}
+		delegateEligibility.DelegatedFrom = delegatedFrom
+	}
+	voterEligibility.DelegatedTo = ""
+
+	err = putVoterV2(stub, voter)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = putVoterV2(stub, delegate)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("delegation revoked %s\n", Id)
+	return shim.Success(nil)
+}
+
+// voters who delegated their ballot to the holder of the eligibility record
+// delegators who have since voted themselves are skipped
+func getDelegators(stub shim.ChaincodeStubInterface, eligibility *ElectionEligibility, electionId string) ([]voterV2, error) {
+	delegators := []voterV2{}
+	for _, delegatorId := range eligibility.DelegatedFrom {
+		delegator, err := getVoterV2(stub, delegatorId)
+		if err != nil {
+			return nil, err
+		}
+		delegatorEligibility := findEligibility(delegator, electionId)
+		if delegatorEligibility == nil || delegatorEligibility.HasVoted {
+			continue
+		}
+		delegators = append(delegators, *delegator)
+	}
+	return delegators, nil
+}
+
+// get a v2 voter from the ledger
+// returns a new voter if the voter has not been stored yet
+func getVoterV2(stub shim.ChaincodeStubInterface, Id string) (*voterV2, error) {
+	voterAsBytes, err := stub.GetState("voter." + Id)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get voter: voter.%s", Id)
+	}
+	voter := &voterV2{ID: Id}
+	if voterAsBytes != nil {
+		json.Unmarshal(voterAsBytes, voter)
+	}
+	return voter, nil
+}
+
+func putVoterV2(stub shim.ChaincodeStubInterface, v *voterV2) error {
+	voterAsBytes, _ := json.Marshal(v)
+	return stub.PutState("voter."+v.ID, voterAsBytes)
+}
+
+// find a voter's eligibility record for an election, adding an empty one if missing
+func findOrAddEligibility(v *voterV2, electionId string) *ElectionEligibility {
+	eligibility := findEligibility(v, electionId)
+	if eligibility == nil {
+		v.ElectionEligibility = append(v.ElectionEligibility, ElectionEligibility{ElectionID: electionId})
+		eligibility = &v.ElectionEligibility[len(v.ElectionEligibility)-1]
+	}
+	return eligibility
+}
+
+// check whether the election's voting window has opened
+func electionHasStarted(stub shim.ChaincodeStubInterface, e *election) (bool, error) {
+	electionStartDate, err := time.Parse(time.RFC3339, e.StartDate)
+	if err != nil {
+		return false, fmt.Errorf("Failed to parse election start date: %s", e.StartDate)
+	}
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return false, fmt.Errorf("Failed to get transaction timestamp")
+	}
+	return !timestamp.AsTime().Before(electionStartDate), nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// a document anchored to a candidate, eg their manifesto
+// only the hash goes on the ledger, the REST API keeps the bytes in a
+// content-addressed store and checks them against this hash when serving them
+type candidateDocument struct {
+	Kind        string `json:"kind"`
+	Hash        string `json:"hash"`
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	AnchoredAt  string `json:"anchoredAt"`
+}
+
+// anchor a document's sha256 to a candidate
+// submitted by the candidate or by a committee member on their behalf
+// every version is kept, the latest of each kind is the current one
+// args: studentID, JSON candidate document
+func (t *VotingChaincode) anchorCandidateDocument(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	studentId := "candidate." + args[0]
+
+	document := candidateDocument{}
+	err := json.Unmarshal([]byte(args[1]), &document)
+	if err != nil {
+		return shim.Error("Invalid candidate document: " + err.Error())
+	}
+	document.Kind = strings.TrimSpace(document.Kind)
+	if document.Kind == "" {
+		return shim.Error("Candidate document requires a kind")
+	}
+	document.Hash = strings.ToLower(document.Hash)
+	if !isSha256Hex(document.Hash) {
+		return shim.Error("Document hash must be a sha256 hex digest")
+	}
+
+	candidate, err := getCandidate(stub, studentId)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = assertCandidateOrCommittee(stub, candidate)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	current := currentDocument(candidate, document.Kind)
+	if current != nil && current.Hash == document.Hash {
+		return errorResponse(&conflictError{"Document is already anchored: " + document.Hash})
+	}
+
+	document.AnchoredAt, err = getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	candidate.Documents = append(candidate.Documents, document)
+
+	candidateAsBytes, _ := json.Marshal(candidate)
+	err = stub.PutState(studentId, candidateAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("candidate document anchored %s %s %s\n", studentId, document.Kind, document.Hash)
+	documentAsBytes, _ := json.Marshal(document)
+	return shim.Success(documentAsBytes)
+}
+
+// latest document of a kind anchored to a candidate
+func currentDocument(c *candidate, kind string) *candidateDocument {
+	for i := len(c.Documents) - 1; i >= 0; i-- {
+		if c.Documents[i].Kind == kind {
+			return &c.Documents[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// who may vote in an election
+// every list left empty matches all students, so an election without
+// a rule is open to everyone
+type eligibilityRule struct {
+	Faculties    []string `json:"faculties,omitempty"`
+	YearsOfStudy []int    `json:"yearsOfStudy,omitempty"`
+	Programmes   []string `json:"programmes,omitempty"`
+}
+
+// voter attributes supplied by the auth layer with each ballot
+type voterAttributes struct {
+	Faculty     string `json:"faculty"`
+	YearOfStudy int    `json:"yearOfStudy"`
+	Programme   string `json:"programme"`
+}
+
+// parse voter attributes passed to the chaincode as a JSON document
+// an empty document means no attributes were supplied
+func parseVoterAttributes(attributesAsJson string) (*voterAttributes, error) {
+	attributes := &voterAttributes{}
+	if attributesAsJson == "" {
+		return attributes, nil
+	}
+	err := json.Unmarshal([]byte(attributesAsJson), attributes)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid voter attributes: %s", err.Error())
+	}
+	return attributes, nil
+}
+
+// check a voter's attributes against an election's eligibility rule
+func checkEligibility(rule *eligibilityRule, attributes *voterAttributes) error {
+	if rule == nil {
+		return nil
+	}
+	if len(rule.Faculties) > 0 && !containsFold(rule.Faculties, attributes.Faculty) {
+		return fmt.Errorf("Voter is not eligible: faculty %s is not in %s", attributes.Faculty, strings.Join(rule.Faculties, ", "))
+	}
+	if len(rule.YearsOfStudy) > 0 {
+		eligible := false
+		years := []string{}
+		for _, year := range rule.YearsOfStudy {
+			eligible = eligible || year == attributes.YearOfStudy
+			years = append(years, strconv.Itoa(year))
+		}
+		if !eligible {
+			return fmt.Errorf("Voter is not eligible: year of study %d is not in %s", attributes.YearOfStudy, strings.Join(years, ", "))
+		}
+	}
+	if len(rule.Programmes) > 0 && !containsFold(rule.Programmes, attributes.Programme) {
+		return fmt.Errorf("Voter is not eligible: programme %s is not in %s", attributes.Programme, strings.Join(rule.Programmes, ", "))
+	}
+	return nil
+}
+
+// faculty and programme names are free text in auth.users, so compare them
+// ignoring case and surrounding spaces
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// check a voter against an election's eligibility rule and, for a
+// faculty-restricted contest, its constituency
+func checkVoterEligibility(stub shim.ChaincodeStubInterface, e *election, attributes *voterAttributes) error {
+	err := checkEligibility(e.Eligibility, attributes)
+	if err != nil {
+		return err
+	}
+	return checkConstituency(stub, e, attributes)
+}
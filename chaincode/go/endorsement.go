@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// organizations whose peers must all endorse changes to an election
+// Org1 and Org2 of test-network by default
+// other keys, ballots included, keep the chaincode-level endorsement policy
+var electionEndorsers = []string{"Org1MSP", "Org2MSP"}
+
+// set a key-level endorsement policy on an election key so every later change
+// needs a peer of each organization in electionEndorsers
+// the policy is only written once, when the election is created or an election
+// stored before key-level policies is next changed
+func requireElectionEndorsement(stub shim.ChaincodeStubInterface, electionId string) error {
+	existing, err := stub.GetStateValidationParameter(electionId)
+	if err != nil {
+		return fmt.Errorf("Failed to get endorsement policy: %s", electionId)
+	}
+	if existing != nil {
+		return nil
+	}
+	ep, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return err
+	}
+	err = ep.AddOrgs(statebased.RoleTypePeer, electionEndorsers...)
+	if err != nil {
+		return err
+	}
+	policy, err := ep.Policy()
+	if err != nil {
+		return err
+	}
+	return stub.SetStateValidationParameter(electionId, policy)
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// a faculty registered on the ledger, which is also the constituency of
+// faculty-restricted contests
+// faculty is free text in auth.users, so a voter's faculty matches when it is
+// the faculty's code, name or one of its aliases
+type faculty struct {
+	FacultyID    string   `json:"facultyID"`
+	Code         string   `json:"code"`
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases"`
+	RegisteredAt string   `json:"registeredAt"`
+}
+
+// results of the contests restricted to a constituency
+// contests open to every faculty are grouped with an empty FacultyID
+type constituencyResult struct {
+	FacultyID string                `json:"facultyID"`
+	Name      string                `json:"name"`
+	Contests  []constituencyContest `json:"contests"`
+}
+
+type constituencyContest struct {
+	ElectionID     string `json:"electionID"`
+	Position       string `json:"position,omitempty"`
+	Winner         string `json:"winner"`
+	TotalBallots   int    `json:"totalBallots"`
+	RunoffRequired bool   `json:"runoffRequired"`
+}
+
+// a key and its value, the shape returned by queryByRange
+type queryResult struct {
+	Key    string          `json:"Key"`
+	Record json.RawMessage `json:"Record"`
+}
+
+// register a faculty, its ID is faculty.<code>
+// args: code, JSON faculty
+func (t *VotingChaincode) registerFaculty(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	code := strings.ToLower(strings.TrimSpace(args[0]))
+	if code == "" || nameKey(code) != code {
+		return shim.Error("Faculty code must be letters and digits: " + args[0])
+	}
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	f := faculty{}
+	err = json.Unmarshal([]byte(args[1]), &f)
+	if err != nil {
+		return shim.Error("Invalid faculty: " + err.Error())
+	}
+	f.FacultyID = "faculty." + code
+	f.Code = code
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Name == "" {
+		return shim.Error("Faculty requires a name")
+	}
+	if f.Aliases == nil {
+		f.Aliases = []string{}
+	}
+
+	faculties, err := getFaculties(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	for _, existing := range faculties {
+		if existing.FacultyID == f.FacultyID {
+			return errorResponse(&conflictError{"Faculty already exists: " + f.FacultyID})
+		}
+		for _, name := range append([]string{f.Name}, f.Aliases...) {
+			if facultyMatches(existing, name) {
+				return errorResponse(&conflictError{name + " is already registered as " + existing.Name})
+			}
+		}
+	}
+
+	f.RegisteredAt, err = getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	facultyAsBytes, _ := json.Marshal(f)
+	err = stub.PutState(f.FacultyID, facultyAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("faculty registered %s\n", f.FacultyID)
+	return shim.Success(facultyAsBytes)
+}
+
+// get every registered faculty
+func (t *VotingChaincode) getAllFaculties(stub shim.ChaincodeStubInterface) pb.Response {
+	faculties, err := getFaculties(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	facultiesAsBytes, _ := json.Marshal(faculties)
+	return shim.Success(facultiesAsBytes)
+}
+
+// get the elections a voter of a faculty can see
+// contests restricted to another constituency are left out, and so are
+// archived elections unless include is "archived"
+// args: faculty as free text, [include]
+func (t *VotingChaincode) getElectionsForFaculty(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 && len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 1 or 2")
+	}
+	includeArchived := len(args) == 2 && args[1] == "archived"
+	faculties, err := getFaculties(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	electionsIterator, err := stub.GetStateByRange("election.", "election.z")
+	if err != nil {
+		return shim.Error("Failed to get elections")
+	}
+	defer electionsIterator.Close()
+
+	results := []queryResult{}
+	for electionsIterator.HasNext() {
+		queryResponse, err := electionsIterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		e := election{}
+		json.Unmarshal(queryResponse.Value, &e)
+		if e.Constituency != "" && !inConstituency(faculties, e.Constituency, args[0]) {
+			continue
+		}
+		if e.Archived && !includeArchived {
+			continue
+		}
+		results = append(results, queryResult{Key: queryResponse.Key, Record: queryResponse.Value})
+	}
+	resultsAsBytes, _ := json.Marshal(results)
+	return shim.Success(resultsAsBytes)
+}
+
+// results of an election grouped by constituency
+// an election created from a ballot definition is reported with the other
+// positions of its ballot
+// args: electionID
+func (t *VotingChaincode) getConstituencyResults(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	e, err := getElection(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	elections := []*election{e}
+	if e.BallotID != "" {
+		elections, err = getBallotElections(stub, e.BallotID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	results := []constituencyResult{}
+	// index of each constituency in results
+	constituencyIndex := map[string]int{}
+	for _, e := range elections {
+		electionResults, err := computeElectionResults(stub, e)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		i, ok := constituencyIndex[e.Constituency]
+		if !ok {
+			result := constituencyResult{FacultyID: e.Constituency, Name: "All faculties", Contests: []constituencyContest{}}
+			if e.Constituency != "" {
+				f, err := getFaculty(stub, e.Constituency)
+				if err != nil {
+					return shim.Error(err.Error())
+				}
+				result.Name = f.Name
+			}
+			i = len(results)
+			constituencyIndex[e.Constituency] = i
+			results = append(results, result)
+		}
+		results[i].Contests = append(results[i].Contests, constituencyContest{
+			ElectionID:     e.ElectionID,
+			Position:       e.Position,
+			Winner:         electionResults.Winner.StudentID,
+			TotalBallots:   electionResults.TotalBallots,
+			RunoffRequired: electionResults.RunoffRequired,
+		})
+	}
+
+	resultsAsBytes, _ := json.Marshal(results)
+	return shim.Success(resultsAsBytes)
+}
+
+// check that a voter belongs to a faculty-restricted election's constituency
+func checkConstituency(stub shim.ChaincodeStubInterface, e *election, attributes *voterAttributes) error {
+	if e.Constituency == "" {
+		return nil
+	}
+	f, err := getFaculty(stub, e.Constituency)
+	if err != nil {
+		return err
+	}
+	if !facultyMatches(*f, attributes.Faculty) {
+		return fmt.Errorf("Voter is not eligible: faculty %s is not in constituency %s", attributes.Faculty, f.Name)
+	}
+	return nil
+}
+
+// check whether a free-text faculty belongs to a constituency
+func inConstituency(faculties []faculty, facultyId string, name string) bool {
+	for _, f := range faculties {
+		if f.FacultyID == facultyId {
+			return facultyMatches(f, name)
+		}
+	}
+	return false
+}
+
+// match a free-text faculty against a faculty's code, name and aliases
+func facultyMatches(f faculty, name string) bool {
+	key := nameKey(name)
+	if key == "" {
+		return false
+	}
+	for _, alias := range append([]string{f.Code, f.Name}, f.Aliases...) {
+		if key == nameKey(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+func getFaculty(stub shim.ChaincodeStubInterface, facultyId string) (*faculty, error) {
+	facultyAsBytes, err := stub.GetState(facultyId)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get faculty: %s", facultyId)
+	}
+	if facultyAsBytes == nil || !strings.HasPrefix(facultyId, "faculty.") {
+		return nil, fmt.Errorf("Faculty does not exist: %s", facultyId)
+	}
+	f := &faculty{}
+	json.Unmarshal(facultyAsBytes, f)
+	return f, nil
+}
+
+func getFaculties(stub shim.ChaincodeStubInterface) ([]faculty, error) {
+	facultiesIterator, err := stub.GetStateByRange("faculty.", "faculty.z")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get faculties")
+	}
+	defer facultiesIterator.Close()
+
+	faculties := []faculty{}
+	for facultiesIterator.HasNext() {
+		queryResponse, err := facultiesIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		f := faculty{}
+		json.Unmarshal(queryResponse.Value, &f)
+		faculties = append(faculties, f)
+	}
+	return faculties, nil
+}
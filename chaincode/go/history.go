@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// a version of a key from the ledger history, oldest first
+type historyEntry struct {
+	TxID      string          `json:"txID"`
+	Timestamp string          `json:"timestamp"`
+	IsDelete  bool            `json:"isDelete"`
+	Value     json.RawMessage `json:"value,omitempty"`
+}
+
+// every committed version of a key
+// requires the peers to keep the history database enabled
+func keyHistory(stub shim.ChaincodeStubInterface, key string) ([]historyEntry, error) {
+	resultsIterator, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get history: %s", key)
+	}
+	defer resultsIterator.Close()
+
+	// the history is returned newest first
+	history := []historyEntry{}
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		entry := historyEntry{
+			TxID:     modification.TxId,
+			IsDelete: modification.IsDelete,
+		}
+		if modification.Timestamp != nil {
+			entry.Timestamp = modification.Timestamp.AsTime().UTC().Format(time.RFC3339)
+		}
+		if !modification.IsDelete {
+			entry.Value = json.RawMessage(modification.Value)
+		}
+		history = append([]historyEntry{entry}, history...)
+	}
+	return history, nil
+}
+
+// a version of an election with the fields changed from the version before it
+type electionVersion struct {
+	historyEntry
+	// identity that submitted the change, empty for versions stored before
+	// elections recorded it
+	Identity string        `json:"identity"`
+	Changes  []fieldChange `json:"changes"`
+}
+
+type fieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// get every version of an election, oldest first, with field-level changes
+// args: electionID
+func (t *VotingChaincode) getElectionHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	electionId := args[0]
+
+	history, err := keyHistory(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if len(history) == 0 {
+		return shim.Error("Election does not exist: " + electionId)
+	}
+
+	versions := []electionVersion{}
+	previous := map[string]interface{}{}
+	for _, entry := range history {
+		current := map[string]interface{}{}
+		if !entry.IsDelete {
+			json.Unmarshal(entry.Value, &current)
+		}
+		version := electionVersion{historyEntry: entry, Changes: diffFields(previous, current)}
+		if updatedBy, ok := current["updatedBy"].(string); ok {
+			version.Identity = updatedBy
+		}
+		versions = append(versions, version)
+		previous = current
+	}
+
+	versionsAsBytes, _ := json.Marshal(versions)
+	return shim.Success(versionsAsBytes)
+}
+
+// fields that differ between two versions of a JSON document, sorted by name
+// the identity stamped on each version is reported separately, not as a change
+func diffFields(from map[string]interface{}, to map[string]interface{}) []fieldChange {
+	fields := map[string]bool{}
+	for field := range from {
+		fields[field] = true
+	}
+	for field := range to {
+		fields[field] = true
+	}
+	delete(fields, "updatedBy")
+
+	changes := []fieldChange{}
+	for field := range fields {
+		if !reflect.DeepEqual(from[field], to[field]) {
+			changes = append(changes, fieldChange{Field: field, From: from[field], To: to[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// check that the submitter is an election committee member
+// committee members are enrolled with the role=committee attribute
+func assertCommittee(stub shim.ChaincodeStubInterface) error {
+	err := cid.AssertAttributeValue(stub, "role", "committee")
+	if err != nil {
+		return fmt.Errorf("Only election committee members can do this")
+	}
+	return nil
+}
+
+// check that the submitter is the candidate, identified by the studentID
+// attribute of their enrollment certificate, or a committee member
+func assertCandidateOrCommittee(stub shim.ChaincodeStubInterface, c *candidate) error {
+	studentId, found, err := cid.GetAttributeValue(stub, "studentID")
+	if err == nil && found && "candidate."+studentId == c.StudentID {
+		return nil
+	}
+	if assertCommittee(stub) == nil {
+		return nil
+	}
+	return fmt.Errorf("Only the candidate or an election committee member can do this")
+}
+
+// identity that submitted the transaction, as MSPID:common name
+// eg Org1MSP:User1@org1.example.com
+func submitterIdentity(stub shim.ChaincodeStubInterface) (string, error) {
+	mspId, err := cid.GetMSPID(stub)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get submitter identity: %s", err.Error())
+	}
+	cert, err := cid.GetX509Certificate(stub)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get submitter identity: %s", err.Error())
+	}
+	// identities without an X.509 certificate, eg idemix, are identified by their ID
+	if cert == nil {
+		id, err := cid.GetID(stub)
+		if err != nil {
+			return "", fmt.Errorf("Failed to get submitter identity: %s", err.Error())
+		}
+		return mspId + ":" + id, nil
+	}
+	return mspId + ":" + cert.Subject.CommonName, nil
+}
@@ -16,17 +16,31 @@ type VotingChaincode struct {
 
 // init ledger with 4 voting cadidates
 type candidate struct {
-	Name      string         `json:"name"`
-	StudentID string         `json:"studentID"`
-	Faculty   string         `json:"faculty"`
-	Party     string         `json:"party"`
-	Avatar    string         `json:"avatar"`
-	Elections []electionInfo `json:"elections"`
+	Name      string `json:"name"`
+	StudentID string `json:"studentID"`
+	Faculty   string `json:"faculty"`
+	Party     string `json:"party"`
+	// registered party, see party.go
+	PartyID string `json:"partyID,omitempty"`
+	Avatar  string `json:"avatar"`
+	// sha256 of an uploaded avatar, empty while Avatar is an external URL
+	AvatarHash string         `json:"avatarHash,omitempty"`
+	Elections  []electionInfo `json:"elections"`
+	// anchored documents such as manifestos, see documents.go
+	Documents []candidateDocument `json:"documents,omitempty"`
 }
 
 type electionInfo struct {
 	ElectionID string `json:"electionID"`
 	Votes      int    `json:"votes"`
+	// sum of the voters' weights, only counted in weighted elections
+	WeightedVotes int    `json:"weightedVotes"`
+	NominatedAt   string `json:"nominatedAt"`
+	// withdrawn or disqualified, empty while the candidate is standing
+	// see candidacy.go
+	Status       string `json:"status,omitempty"`
+	StatusReason string `json:"statusReason,omitempty"`
+	StatusAt     string `json:"statusAt,omitempty"`
 }
 
 // voter struct
@@ -35,6 +49,7 @@ type voter struct {
 	HasVoted   bool   `json:"hasVoted"`
 	ElectionID string `json:"electionID"`
 	Email      string `json:"email"`
+	Faculty    string `json:"faculty,omitempty"`
 }
 
 type voterV2 struct {
@@ -45,6 +60,17 @@ type voterV2 struct {
 type ElectionEligibility struct {
 	ElectionID string `json:"electionID"`
 	HasVoted   bool   `json:"hasVoted"`
+	// vote weight assigned by the admin for weighted elections
+	Weight int `json:"weight,omitempty"`
+	// voter this voter's ballot is delegated to, see delegation.go
+	DelegatedTo string `json:"delegatedTo,omitempty"`
+	// voters who delegated their ballot to this voter
+	DelegatedFrom []string `json:"delegatedFrom,omitempty"`
+	// last ballot cast and what it counted for, only kept for elections
+	// that allow re-voting so a superseded ballot can be taken out of the count
+	Ballot       string `json:"ballot,omitempty"`
+	BallotCount  int    `json:"ballotCount,omitempty"`
+	BallotWeight int    `json:"ballotWeight,omitempty"`
 }
 
 type election struct {
@@ -54,11 +80,138 @@ type election struct {
 	EndDate      string  `json:"endDate"`
 	CreatedAt    string  `json:"createdAt"`
 	UpdatedAt    *string `json:"updatedAt"`
+	// tie-break policy applied by the tally, see tiebreak.go
+	TieBreak string `json:"tieBreak"`
+	// sha256 of the lot seed, committed when the election is created
+	SeedHash string `json:"seedHash,omitempty"`
+	// lot seed, revealed by the committee once voting has ended
+	Seed string `json:"seed,omitempty"`
+	// candidate chosen by the committee to break a tie
+	CommitteeDecision string `json:"committeeDecision,omitempty"`
+	// percentage of candidate votes the winner must exceed, 0 elects by plurality
+	MajorityThreshold int `json:"majorityThreshold"`
+	// lifecycle status, see lifecycle.go
+	Status string `json:"status"`
+	// election this one is a runoff of
+	RunoffOf string `json:"runoffOf,omitempty"`
+	// runoff created when this election was closed
+	RunoffElectionID string `json:"runoffElectionID,omitempty"`
+	// standard or weighted, see weighted.go
+	Type string `json:"type"`
+	// voters may replace their ballot until the election closes
+	AllowRevote bool `json:"allowRevote"`
+	// who may vote, see eligibility.go
+	Eligibility *eligibilityRule `json:"eligibility,omitempty"`
+	// ballots are only accepted from voters in a frozen voter roll, see roll.go
+	FrozenRoll   bool   `json:"frozenRoll"`
+	RollFrozen   bool   `json:"rollFrozen"`
+	RollFrozenAt string `json:"rollFrozenAt,omitempty"`
+	RollSize     int    `json:"rollSize"`
+	// election whose voter roll this election uses
+	RollOf string `json:"rollOf,omitempty"`
+	// identity that submitted the transaction which stored this version
+	UpdatedBy string `json:"updatedBy,omitempty"`
+	// faculty ID of a faculty-restricted contest, see faculty.go
+	Constituency string `json:"constituency,omitempty"`
+	// position contested, for elections created from a ballot definition
+	Position string `json:"position,omitempty"`
+	// ballot definition the election was created from, shared by its positions
+	BallotID string `json:"ballotID,omitempty"`
+	// candidates are nominated, seconded and approved before reaching the ballot
+	// see nomination.go
+	Nominations bool `json:"nominations"`
+	// number of eligible students who must second a nomination
+	Seconders int `json:"seconders"`
+	// ballots are rejected while the election is suspended, see suspension.go
+	Suspended   bool         `json:"suspended"`
+	Suspensions []suspension `json:"suspensions,omitempty"`
+	// push the end date back by the voting time lost to a suspension
+	ExtendOnResume bool `json:"extendOnResume"`
+	// why and when the election was cancelled, see lifecycle.go
+	CancelReason string `json:"cancelReason,omitempty"`
+	CancelledAt  string `json:"cancelledAt,omitempty"`
+	// archived elections are left out of election listings
+	Archived   bool   `json:"archived"`
+	ArchivedAt string `json:"archivedAt,omitempty"`
+	// results signed by the threshold of certifying organizations
+	// see certification.go
+	Certified   bool   `json:"certified"`
+	CertifiedAt string `json:"certifiedAt,omitempty"`
+	ResultsHash string `json:"resultsHash,omitempty"`
+}
+
+// optional settings passed to createElection as a JSON document
+type electionOptions struct {
+	TieBreak          string           `json:"tieBreak"`
+	SeedHash          string           `json:"seedHash"`
+	MajorityThreshold int              `json:"majorityThreshold"`
+	Type              string           `json:"type"`
+	AllowRevote       bool             `json:"allowRevote"`
+	Eligibility       *eligibilityRule `json:"eligibility"`
+	FrozenRoll        bool             `json:"frozenRoll"`
+	Nominations       bool             `json:"nominations"`
+	Seconders         int              `json:"seconders"`
+	Constituency      string           `json:"constituency"`
+	ExtendOnResume    bool             `json:"extendOnResume"`
+}
+
+// ballot IDs that can be passed to voteV2 in place of a CandidateID
+// reopenNominationsBallot is only accepted when a single candidate stands,
+// which turns the contest into a confirmation vote
+const (
+	abstainBallot           = "abstain"
+	blankBallot             = "blank"
+	reopenNominationsBallot = "reopen"
+)
+
+// abstain, blank and re-open nominations ballots are not cast for any candidate
+// so they are counted separately per election
+type electionTally struct {
+	ElectionID        string `json:"electionID"`
+	Abstain           int    `json:"abstain"`
+	Blank             int    `json:"blank"`
+	ReopenNominations int    `json:"reopenNominations"`
+	// same counts summed by voter weight, only used by weighted elections
+	WeightedAbstain           int `json:"weightedAbstain"`
+	WeightedBlank             int `json:"weightedBlank"`
+	WeightedReopenNominations int `json:"weightedReopenNominations"`
+}
+
+// outcome of an uncontested election, the candidate is confirmed
+// only if they receive more votes than "Re-open nominations"
+type confirmationResult struct {
+	Candidate string `json:"candidate"`
+	Yes       int    `json:"yes"`
+	No        int    `json:"no"`
+	Confirmed bool   `json:"confirmed"`
 }
 
 type electionResults struct {
-	Candidates []candidate `json:"candidates"`
-	Winner     candidate   `json:"winner"`
+	ElectionID   string      `json:"electionID"`
+	Candidates   []candidate `json:"candidates"`
+	Winner       candidate   `json:"winner"`
+	TotalVotes   int         `json:"totalVotes"`
+	Abstain      int         `json:"abstain"`
+	Blank        int         `json:"blank"`
+	TotalBallots int         `json:"totalBallots"`
+	// weighted totals, set for weighted elections
+	// withdrawn and disqualified candidates, and the void ballots cast for them
+	Excluded             []candidate `json:"excluded"`
+	Void                 int         `json:"void"`
+	WeightedVoid         int         `json:"weightedVoid"`
+	Weighted             bool        `json:"weighted"`
+	TotalWeightedVotes   int         `json:"totalWeightedVotes"`
+	WeightedAbstain      int         `json:"weightedAbstain"`
+	WeightedBlank        int         `json:"weightedBlank"`
+	TotalWeightedBallots int         `json:"totalWeightedBallots"`
+	// set when only one candidate stands for the election
+	Confirmation *confirmationResult `json:"confirmation,omitempty"`
+	// set when two or more candidates share the highest number of votes
+	Tie *tieBreakResult `json:"tie,omitempty"`
+	// no winner until a runoff is held, either because the majority
+	// threshold was not reached or the tie-break policy is runoff
+	RunoffRequired   bool   `json:"runoffRequired"`
+	RunoffElectionID string `json:"runoffElectionID,omitempty"`
 }
 
 func main() {
@@ -88,16 +241,98 @@ func (t *VotingChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		return t.createElection(stub, args)
 	} else if function == "createVoter" {
 		return t.createVoter(stub, args)
+	} else if function == "createVoters" {
+		return t.createVoters(stub, args)
 	} else if function == "createCandidate" {
 		return t.createCandidate(stub, args)
+	} else if function == "applyBallotDefinition" {
+		return t.applyBallotDefinition(stub, args)
+	} else if function == "submitNomination" {
+		return t.submitNomination(stub, args)
+	} else if function == "secondNomination" {
+		return t.secondNomination(stub, args)
+	} else if function == "decideNomination" {
+		return t.decideNomination(stub, args)
+	} else if function == "updateCandidate" {
+		return t.updateCandidate(stub, args)
+	} else if function == "getCandidateById" {
+		return t.getCandidateById(stub, args)
+	} else if function == "getCandidateHistory" {
+		return t.getCandidateHistory(stub, args)
+	} else if function == "registerParty" {
+		return t.registerParty(stub, args)
+	} else if function == "getAllParties" {
+		return t.getAllParties(stub)
+	} else if function == "getPartyById" {
+		return t.getPartyById(stub, args)
+	} else if function == "getPartyResults" {
+		return t.getPartyResults(stub, args)
+	} else if function == "registerFaculty" {
+		return t.registerFaculty(stub, args)
+	} else if function == "getAllFaculties" {
+		return t.getAllFaculties(stub)
+	} else if function == "getElectionsForFaculty" {
+		return t.getElectionsForFaculty(stub, args)
+	} else if function == "getConstituencyResults" {
+		return t.getConstituencyResults(stub, args)
+	} else if function == "setCandidateAvatar" {
+		return t.setCandidateAvatar(stub, args)
+	} else if function == "anchorCandidateDocument" {
+		return t.anchorCandidateDocument(stub, args)
+	} else if function == "getElectionHistory" {
+		return t.getElectionHistory(stub, args)
+	} else if function == "withdrawCandidate" {
+		return t.withdrawCandidate(stub, args)
+	} else if function == "disqualifyCandidate" {
+		return t.disqualifyCandidate(stub, args)
+	} else if function == "getNominations" {
+		return t.getNominations(stub, args)
 	} else if function == "getElectionById" {
 		return t.getElectionById(stub, args)
 	} else if function == "getAllElections" {
 		return t.getAllElections(stub)
 	} else if function == "updateElection" {
 		return t.updateElection(stub, args)
+	} else if function == "certifyResults" {
+		return t.certifyResults(stub, args)
+	} else if function == "getResultsDocument" {
+		return t.getResultsDocument(stub, args)
+	} else if function == "getCertification" {
+		return t.getCertification(stub, args)
+	} else if function == "cancelElection" {
+		return t.cancelElection(stub, args)
+	} else if function == "archiveElection" {
+		return t.archiveElection(stub, args)
+	} else if function == "getElections" {
+		return t.getElections(stub, args)
+	} else if function == "suspendElection" {
+		return t.suspendElection(stub, args)
+	} else if function == "resumeElection" {
+		return t.resumeElection(stub, args)
+	} else if function == "approveAmendment" {
+		return t.approveAmendment(stub, args)
+	} else if function == "getAmendments" {
+		return t.getAmendments(stub, args)
 	} else if function == "getCandidatesById" {
 		return t.getCandidatesById(stub, args)
+	} else if function == "getElectionResults" {
+		return t.getElectionResults(stub, args)
+	} else if function == "revealTieBreakSeed" {
+		return t.revealTieBreakSeed(stub, args)
+	} else if function == "decideTie" {
+		return t.decideTie(stub, args)
+	} else if function == "closeElection" {
+		return t.closeElection(stub, args)
+	} else if function == "setVoterWeight" {
+		return t.setVoterWeight(stub, args)
+	} else if function == "delegateVote" {
+		return t.delegateVote(stub, args)
+	} else if function == "revokeDelegation" {
+		return t.revokeDelegation(stub, args)
+	} else if function == "addVoterRollEntries" {
+		return t.addVoterRollEntries(stub, args)
+	} else if function == "freezeVoterRoll" {
+		return t.freezeVoterRoll(stub, args)
 	} else if function == "queryByRange" {
 		return t.queryByRange(stub, args)
 	}
@@ -112,8 +347,36 @@ func (t *VotingChaincode) Query(stub shim.ChaincodeStubInterface) pb.Response {
 		return t.getElectionById(stub, args)
 	} else if function == "getAllElections" {
 		return t.getAllElections(stub)
+	} else if function == "getElections" {
+		return t.getElections(stub, args)
+	} else if function == "getResultsDocument" {
+		return t.getResultsDocument(stub, args)
+	} else if function == "getCertification" {
+		return t.getCertification(stub, args)
 	} else if function == "getCandidatesById" {
 		return t.getCandidatesById(stub, args)
+	} else if function == "getElectionResults" {
+		return t.getElectionResults(stub, args)
+	} else if function == "getCandidateById" {
+		return t.getCandidateById(stub, args)
+	} else if function == "getCandidateHistory" {
+		return t.getCandidateHistory(stub, args)
+	} else if function == "getElectionHistory" {
+		return t.getElectionHistory(stub, args)
+	} else if function == "getAmendments" {
+		return t.getAmendments(stub, args)
+	} else if function == "getAllParties" {
+		return t.getAllParties(stub)
+	} else if function == "getPartyById" {
+		return t.getPartyById(stub, args)
+	} else if function == "getPartyResults" {
+		return t.getPartyResults(stub, args)
+	} else if function == "getAllFaculties" {
+		return t.getAllFaculties(stub)
+	} else if function == "getElectionsForFaculty" {
+		return t.getElectionsForFaculty(stub, args)
+	} else if function == "getConstituencyResults" {
+		return t.getConstituencyResults(stub, args)
 	} else if function == "queryByRange" {
 		return t.queryByRange(stub, args)
 	}
@@ -127,33 +390,18 @@ func (t *VotingChaincode) createVoter(stub shim.ChaincodeStubInterface, args []s
 	if len(args) != 3 {
 		return shim.Error("Incorrect number of arguments. Expecting 3")
 	}
-	studentId := "voter." + args[0]
 	electionId := args[1]
 	email := args[2]
 
-	var newVoter = voter{StudentID: studentId, HasVoted: false, ElectionID: electionId, Email: email}
-
-	// find voter in ledger
-	dupeVoterAsBytes, err := stub.GetState(studentId)
-	if err != nil {
-		return shim.Error("Failed to get voter: " + studentId)
-	}
-	dupeVoter := voter{}
-	// if voter exists, return error
-	if dupeVoterAsBytes != nil {
-		json.Unmarshal(dupeVoterAsBytes, &dupeVoter)
-		if dupeVoter.StudentID == studentId {
-			return shim.Error("Voter already exists")
-		}
-	}
-
-	newVoterAsBytes, _ := json.Marshal(newVoter)
-	err = stub.PutState(args[0], newVoterAsBytes)
-
+	created, err := registerVoter(stub, args[0], electionId, email, "")
 	if err != nil {
 		fmt.Println("Error creating voter")
 		return shim.Error(err.Error())
 	}
+	// a student can be registered for several elections, but only once for each
+	if !created {
+		return shim.Error("Voter is already registered for this election")
+	}
 	fmt.Println("Voter created")
 	return shim.Success(nil)
 
@@ -166,15 +414,28 @@ func (t *VotingChaincode) vote(stub shim.ChaincodeStubInterface, args []string)
 	candidateId := args[1]
 	electionId := args[2]
 
-	voterAsBytes, err := stub.GetState(voterId)
+	election, err := getElection(stub, electionId)
 	if err != nil {
-		return shim.Error("Failed to get voter: " + voterId)
+		return shim.Error(err.Error())
+	}
+	registration, voterKey, err := getVoterRegistration(stub, electionId, voterId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	voter := voter{StudentID: "voter." + voterId, ElectionID: electionId}
+	if registration != nil {
+		voter = *registration
 	}
-	voter := voter{}
-	json.Unmarshal(voterAsBytes, &voter)
 	if voter.HasVoted {
 		return shim.Error("Voter has already voted")
 	}
+	// the faculty recorded at registration decides faculty-restricted contests
+	if voter.Faculty != "" {
+		err = checkConstituency(stub, election, &voterAttributes{Faculty: voter.Faculty})
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
 
 	// update candidate votes
 	candidateAsBytes, err := stub.GetState(candidateId)
@@ -186,6 +447,9 @@ func (t *VotingChaincode) vote(stub shim.ChaincodeStubInterface, args []string)
 	contestedElection := candidate.Elections
 	for i := 0; i < len(contestedElection); i++ {
 		if contestedElection[i].ElectionID == electionId {
+			if contestedElection[i].Status != "" {
+				return shim.Error("Candidate is " + contestedElection[i].Status)
+			}
 			contestedElection[i].Votes++
 		}
 	}
@@ -195,8 +459,8 @@ func (t *VotingChaincode) vote(stub shim.ChaincodeStubInterface, args []string)
 	stub.PutState(candidateId, candidateAsBytes)
 
 	voter.HasVoted = true
-	voterAsBytes, _ = json.Marshal(voter)
-	stub.PutState(voterId, voterAsBytes)
+	voterAsBytes, _ := json.Marshal(voter)
+	stub.PutState(voterKey, voterAsBytes)
 
 	return shim.Success(nil)
 }
@@ -208,89 +472,542 @@ func (t *VotingChaincode) vote(stub shim.ChaincodeStubInterface, args []string)
 // if generated id is found in the ledger, but election id is not found, update the voter and store in ledger
 // this means we need a new voter model, the current can only store one election id
 // and its checked using hasVoted flag.
+// an optional 4th argument holds the voter's attributes from the auth layer
+// as a JSON document, checked against the election's eligibility rule
 func (t *VotingChaincode) voteV2(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 && len(args) != 4 {
+		return shim.Error("Incorrect number of arguments. Expecting 3 or 4")
+	}
 	Id := args[0]
 	CandidateID := args[1]
 	ElectionID := args[2]
-
-	if len(args) != 3 {
-		return shim.Error("Incorrect number of arguments. Expecting 3")
+	attributesAsJson := ""
+	if len(args) == 4 {
+		attributesAsJson = args[3]
+	}
+	attributes, err := parseVoterAttributes(attributesAsJson)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
+
 	VoterID := "voter." + Id
 	// find voter in ledger
 	voterAsBytes, err := stub.GetState(VoterID)
 	if err != nil {
 		return shim.Error("Failed to get voter: " + VoterID)
 	}
-
-	// if voter exists, check if election id exist
+	// if voter does not exist, a new voter is stored once the ballot is counted
+	voter := voterV2{ID: Id}
 	if voterAsBytes != nil {
-		voter := voterV2{}
 		json.Unmarshal(voterAsBytes, &voter)
-
-		// if election id exist, return error
-		for i := 0; i < len(voter.ElectionEligibility); i++ {
-			if voter.ElectionEligibility[i].ElectionID == ElectionID && voter.ElectionEligibility[i].HasVoted {
-				fmt.Printf("Voter has already voted for this election")
-				return shim.Error("Voter has already voted")
-			}
-		}
 	}
 
+	eligibility := findEligibility(&voter, ElectionID)
+
 	// get election
-	electionAsBytes, err := stub.GetState(ElectionID)
-	election := election{}
-	json.Unmarshal(electionAsBytes, &election)
+	election, err := getElection(stub, ElectionID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	// the voting window is checked against the transaction timestamp,
+	// which unlike time.Now() is the same on every endorsing peer
+	started, err := electionHasStarted(stub, election)
 	if err != nil {
-		return shim.Error("Failed to get election: " + ElectionID)
+		return shim.Error(err.Error())
+	}
+	if !started {
+		return shim.Error("Election has not started")
 	}
-	// parse election end date to datetime
 	electionEndDate, err := time.Parse(time.RFC3339, election.EndDate)
 	if err != nil {
 		return shim.Error("Failed to parse election end date: " + election.EndDate)
 	}
-	// check if election has ended
-	if time.Now().After(electionEndDate) {
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error("Failed to get transaction timestamp")
+	}
+	if timestamp.AsTime().After(electionEndDate) {
 		return shim.Error("Election has ended")
 	}
+	if election.Status == electionClosed {
+		return shim.Error("Election is closed")
+	}
+	if election.Suspended {
+		return shim.Error("Election is suspended")
+	}
+	// a frozen roll is the electorate, so a voter in it keeps their ballot
+	// even if their enrolment changes while the election is open
+	if election.FrozenRoll {
+		err = checkVoterRoll(stub, election, Id)
+	} else {
+		err = checkVoterEligibility(stub, election, attributes)
+	}
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// if voter has voted in this election, return error
+	// unless the election lets voters replace their own ballot
+	if eligibility != nil && eligibility.HasVoted {
+		if !election.AllowRevote || eligibility.Ballot == "" {
+			fmt.Printf("Voter has already voted for this election")
+			return shim.Error("Voter has already voted")
+		}
+		// the replacement carries the same ballots and weight as the
+		// superseded ballot, including any delegated to this voter
+		err = recountBallot(stub, election, eligibility.Ballot, CandidateID, eligibility.BallotCount, eligibility.BallotWeight)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		eligibility.Ballot = CandidateID
+		voterAsBytes, _ = json.Marshal(voter)
+		err = stub.PutState(VoterID, voterAsBytes)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	}
+	// a delegated ballot is cast by the delegate unless the delegation is revoked
+	if eligibility != nil && eligibility.DelegatedTo != "" {
+		return shim.Error("Voter has delegated their vote to " + eligibility.DelegatedTo)
+	}
+
+	// in a weighted election only voters given a weight by the admin can vote
+	weight := 1
+	if election.Type == electionWeighted {
+		if eligibility == nil || eligibility.Weight <= 0 {
+			return shim.Error("Voter has no vote weight for this election")
+		}
+		weight = eligibility.Weight
+	}
+
+	// a delegate's ballot also counts for every voter who delegated to them
+	ballots := 1
+	delegators := []voterV2{}
+	if eligibility != nil {
+		delegators, err = getDelegators(stub, eligibility, ElectionID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	for i := 0; i < len(delegators); i++ {
+		// delegators left out of the frozen roll keep their ballot
+		if checkVoterRoll(stub, election, delegators[i].ID) != nil {
+			delegators = append(delegators[:i], delegators[i+1:]...)
+			i--
+		}
+	}
+	for _, delegator := range delegators {
+		ballots++
+		if election.Type == electionWeighted {
+			weight += findEligibility(&delegator, ElectionID).Weight
+		} else {
+			weight++
+		}
+	}
+
+	// reject the ballot before the voter is marked as voted
+	// otherwise the voter loses their eligibility for a ballot that was never counted
+	err = countBallot(stub, election, CandidateID, ballots, weight)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// delegated ballots are used up with the delegate's
+	for _, delegator := range delegators {
+		findEligibility(&delegator, ElectionID).HasVoted = true
+		delegatorAsBytes, _ := json.Marshal(delegator)
+		err = stub.PutState("voter."+delegator.ID, delegatorAsBytes)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	// update voter ledger
+	eligibility = findOrAddEligibility(&voter, ElectionID)
+	eligibility.HasVoted = true
+	// the choice is only kept when it may be superseded later
+	if election.AllowRevote {
+		eligibility.Ballot = CandidateID
+		eligibility.BallotCount = ballots
+		eligibility.BallotWeight = weight
+	}
+	voterAsBytes, _ = json.Marshal(voter)
+	err = stub.PutState(VoterID, voterAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// find a voter's eligibility record for an election
+// returns nil if the voter has none
+func findEligibility(v *voterV2, electionId string) *ElectionEligibility {
+	for i := 0; i < len(v.ElectionEligibility); i++ {
+		if v.ElectionEligibility[i].ElectionID == electionId {
+			return &v.ElectionEligibility[i]
+		}
+	}
+	return nil
+}
+
+// count a ballot for a candidate, or in the election tally for
+// abstain, blank and re-open nominations ballots
+// ballots is more than 1 when a delegate votes on behalf of others
+// weight equals ballots unless the election is weighted
+// negative ballots and weight take a superseded ballot out of the count
+func countBallot(stub shim.ChaincodeStubInterface, e *election, candidateId string, ballots int, weight int) error {
+	if isTallyBallot(candidateId) {
+		tally, err := getElectionTally(stub, e.ElectionID)
+		if err != nil {
+			return err
+		}
+		err = addToTally(stub, e, tally, candidateId, ballots, weight)
+		if err != nil {
+			return err
+		}
+		tallyAsBytes, _ := json.Marshal(tally)
+		return stub.PutState(tallyKey(e.ElectionID), tallyAsBytes)
+	}
 
 	// update candidate votes
-	candidateAsBytes, err := stub.GetState(CandidateID)
+	candidateAsBytes, err := stub.GetState(candidateId)
 	if err != nil {
-		return shim.Error("Failed to get candidate: " + CandidateID)
+		return fmt.Errorf("Failed to get candidate: %s", candidateId)
+	}
+	if candidateAsBytes == nil {
+		return fmt.Errorf("Candidate does not exist: %s", candidateId)
 	}
 	candidate := candidate{}
 	json.Unmarshal(candidateAsBytes, &candidate)
-	contestedElection := candidate.Elections
-	for i := 0; i < len(contestedElection); i++ {
-		fmt.Println(contestedElection[i].ElectionID)
-		if contestedElection[i].ElectionID == ElectionID {
-			contestedElection[i].Votes++
+	counted := false
+	for i := 0; i < len(candidate.Elections); i++ {
+		if candidate.Elections[i].ElectionID == e.ElectionID {
+			// a ballot moved away from a withdrawn or disqualified candidate
+			// by a revote is still taken off their count
+			if ballots > 0 && candidate.Elections[i].Status != "" {
+				return fmt.Errorf("Candidate is %s: %s", candidate.Elections[i].Status, candidateId)
+			}
+			candidate.Elections[i].Votes += ballots
+			candidate.Elections[i].WeightedVotes += weight
+			counted = true
 		}
 	}
-	candidate.Elections = contestedElection
+	if !counted {
+		return fmt.Errorf("Candidate is not contesting election: %s", e.ElectionID)
+	}
 	candidateAsBytes, _ = json.Marshal(candidate)
-	stub.PutState(CandidateID, candidateAsBytes)
-	// candidate votes ledger updated when
+	return stub.PutState(candidateId, candidateAsBytes)
+}
 
-	electionEligibility := ElectionEligibility{ElectionID: ElectionID, HasVoted: true}
-	// update voter ledger
-	// if voter does not exist, create new voter
-	if voterAsBytes == nil {
-		voter := voterV2{}
-		voter.ID = Id
-		voter.ElectionEligibility = append(voter.ElectionEligibility, electionEligibility)
-		voterAsBytes, _ = json.Marshal(voter)
-		stub.PutState(VoterID, voterAsBytes)
+// move a voter's ballot from one choice to another
+func recountBallot(stub shim.ChaincodeStubInterface, e *election, fromId string, toId string, ballots int, weight int) error {
+	if fromId == toId {
+		return nil
+	}
+	// when both choices live in the tally it is read and written once
+	if isTallyBallot(fromId) && isTallyBallot(toId) {
+		tally, err := getElectionTally(stub, e.ElectionID)
+		if err != nil {
+			return err
+		}
+		err = addToTally(stub, e, tally, fromId, -ballots, -weight)
+		if err != nil {
+			return err
+		}
+		err = addToTally(stub, e, tally, toId, ballots, weight)
+		if err != nil {
+			return err
+		}
+		tallyAsBytes, _ := json.Marshal(tally)
+		return stub.PutState(tallyKey(e.ElectionID), tallyAsBytes)
+	}
+	err := countBallot(stub, e, fromId, -ballots, -weight)
+	if err != nil {
+		return err
+	}
+	return countBallot(stub, e, toId, ballots, weight)
+}
+
+// abstain, blank and re-open nominations ballots are counted in the election tally
+func isTallyBallot(ballotId string) bool {
+	return ballotId == abstainBallot || ballotId == blankBallot || ballotId == reopenNominationsBallot
+}
+
+// add ballots of one tally option to the tally
+func addToTally(stub shim.ChaincodeStubInterface, e *election, tally *electionTally, ballotId string, ballots int, weight int) error {
+	if ballotId == abstainBallot {
+		tally.Abstain += ballots
+		tally.WeightedAbstain += weight
+	} else if ballotId == blankBallot {
+		tally.Blank += ballots
+		tally.WeightedBlank += weight
 	} else {
-		// if voter exist, update voter
-		voter := voterV2{}
-		json.Unmarshal(voterAsBytes, &voter)
-		voter.ElectionEligibility = append(voter.ElectionEligibility, electionEligibility)
-		voterAsBytes, _ = json.Marshal(voter)
-		stub.PutState(VoterID, voterAsBytes)
+		// re-open nominations is the "no" option of a confirmation vote
+		// and has no meaning when the election is contested
+		if ballots > 0 {
+			candidates, err := getElectionCandidates(stub, e.ElectionID)
+			if err != nil {
+				return err
+			}
+			standing, _ := standingCandidates(candidates, e.ElectionID)
+			if len(standing) != 1 {
+				return fmt.Errorf("Re-open nominations is only available for uncontested elections")
+			}
+		}
+		tally.ReopenNominations += ballots
+		tally.WeightedReopenNominations += weight
 	}
-	return shim.Success(nil)
+	return nil
+}
+
+// tally key for an election, kept out of the election. range
+// so that getAllElections only lists elections
+func tallyKey(electionId string) string {
+	return "tally." + electionId
+}
+
+// key of a record kept per election, eg a nomination or a voter roll entry
+// these records are stored under composite keys so they stay out of the
+// election., candidate. and voter. ranges used by queryByRange, and an
+// election's records are listed with GetStateByPartialCompositeKey
+// GetState does not see writes made earlier in the same transaction, so
+// records changed more than once in a call are kept in memory until written
+func electionRecordKey(stub shim.ChaincodeStubInterface, objectType string, electionId string, id string) (string, error) {
+	return stub.CreateCompositeKey(objectType, []string{electionId, id})
+}
+
+// get the abstain and blank ballot counts for an election
+// returns an empty tally if no such ballot has been cast yet
+func getElectionTally(stub shim.ChaincodeStubInterface, electionId string) (*electionTally, error) {
+	tally := &electionTally{ElectionID: electionId}
+	tallyAsBytes, err := stub.GetState(tallyKey(electionId))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get tally: %s", electionId)
+	}
+	if tallyAsBytes != nil {
+		json.Unmarshal(tallyAsBytes, tally)
+	}
+	return tally, nil
+}
+
+// get every candidate contesting an election
+func getElectionCandidates(stub shim.ChaincodeStubInterface, electionId string) ([]candidate, error) {
+	candidatesIterator, err := stub.GetStateByRange("candidate.", "candidate.z")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get candidates: %s", electionId)
+	}
+	defer candidatesIterator.Close()
+
+	candidates := []candidate{}
+	for candidatesIterator.HasNext() {
+		queryResponse, err := candidatesIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		candidate := candidate{}
+		json.Unmarshal(queryResponse.Value, &candidate)
+		for _, info := range candidate.Elections {
+			if info.ElectionID == electionId {
+				candidates = append(candidates, candidate)
+				break
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// votes a candidate received in an election
+// weighted elections count the voters' weights instead of ballots
+func candidateVotes(c candidate, e *election) int {
+	votes := 0
+	for _, info := range c.Elections {
+		if info.ElectionID != e.ElectionID {
+			continue
+		}
+		if e.Type == electionWeighted {
+			votes += info.WeightedVotes
+		} else {
+			votes += info.Votes
+		}
+	}
+	return votes
+}
+
+// get election results function
+func (t *VotingChaincode) getElectionResults(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	electionId := args[0]
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	results, err := computeElectionResults(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	resultsAsBytes, _ := json.Marshal(results)
+	return shim.Success(resultsAsBytes)
+}
+
+// collects every candidate contesting the election along with the
+// abstain and blank ballots counted for it, then decides the winner
+func computeElectionResults(stub shim.ChaincodeStubInterface, e *election) (*electionResults, error) {
+	candidates, err := getElectionCandidates(stub, e.ElectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// withdrawn and disqualified candidates cannot win
+	// ballots already cast for them are void, counted as ballots but not as candidate votes
+	candidates, excluded := standingCandidates(candidates, e.ElectionID)
+	results := &electionResults{ElectionID: e.ElectionID, Candidates: candidates, Excluded: excluded, RunoffElectionID: e.RunoffElectionID}
+	for _, candidate := range excluded {
+		for _, info := range candidate.Elections {
+			if info.ElectionID == e.ElectionID {
+				results.Void += info.Votes
+				results.WeightedVoid += info.WeightedVotes
+			}
+		}
+	}
+	topVotes := -1
+	// votes the winner is decided by, weighted in weighted elections
+	countedVotes := 0
+	for _, candidate := range candidates {
+		for _, info := range candidate.Elections {
+			if info.ElectionID == e.ElectionID {
+				results.TotalVotes += info.Votes
+				results.TotalWeightedVotes += info.WeightedVotes
+			}
+		}
+		votes := candidateVotes(candidate, e)
+		countedVotes += votes
+		if votes > topVotes {
+			topVotes = votes
+			results.Winner = candidate
+		}
+	}
+
+	tally, err := getElectionTally(stub, e.ElectionID)
+	if err != nil {
+		return nil, err
+	}
+	results.Abstain = tally.Abstain
+	results.Blank = tally.Blank
+	results.TotalBallots = results.TotalVotes + results.Void + tally.Abstain + tally.Blank + tally.ReopenNominations
+	reopenNominations := tally.ReopenNominations
+	if e.Type == electionWeighted {
+		results.Weighted = true
+		results.WeightedAbstain = tally.WeightedAbstain
+		results.WeightedBlank = tally.WeightedBlank
+		results.TotalWeightedBallots = results.TotalWeightedVotes + results.WeightedVoid + tally.WeightedAbstain + tally.WeightedBlank + tally.WeightedReopenNominations
+		reopenNominations = tally.WeightedReopenNominations
+	}
+
+	// every candidate withdrew or was disqualified
+	if len(candidates) == 0 {
+		results.Winner = candidate{}
+		return results, nil
+	}
+
+	// a single candidate is not elected by default, the contest becomes
+	// a confirmation vote against re-opening nominations
+	if len(candidates) == 1 {
+		confirmation := &confirmationResult{
+			Candidate: candidates[0].StudentID,
+			Yes:       topVotes,
+			No:        reopenNominations,
+		}
+		confirmation.Confirmed = confirmation.Yes > confirmation.No
+		if !confirmation.Confirmed {
+			results.Winner = candidate{}
+		}
+		results.Confirmation = confirmation
+		return results, nil
+	}
+
+	tied := []candidate{}
+	for _, candidate := range candidates {
+		if candidateVotes(candidate, e) == topVotes {
+			tied = append(tied, candidate)
+		}
+	}
+	if len(tied) > 1 {
+		winner, tie := applyTieBreak(e, tied)
+		results.Winner = winner
+		results.Tie = tie
+		if e.TieBreak == tieBreakRunoff {
+			results.RunoffRequired = true
+			return results, nil
+		}
+	}
+
+	// the leading candidate must take more than the threshold share of
+	// candidate votes, abstain and blank ballots do not count towards it
+	if e.MajorityThreshold > 0 && topVotes*100 <= e.MajorityThreshold*countedVotes {
+		results.Winner = candidate{}
+		results.RunoffRequired = true
+	}
+	return results, nil
+}
+
+// store an election, stamped with the identity that submitted the transaction
+// so every version in the election's history shows who made the change
+func putElection(stub shim.ChaincodeStubInterface, e *election) ([]byte, error) {
+	updatedBy, err := submitterIdentity(stub)
+	if err != nil {
+		return nil, err
+	}
+	e.UpdatedBy = updatedBy
+	electionAsBytes, _ := json.Marshal(e)
+	err = stub.PutState(e.ElectionID, electionAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	// changes to the election need both organizations, see endorsement.go
+	return electionAsBytes, requireElectionEndorsement(stub, e.ElectionID)
+}
+
+// get an election from the ledger, erroring if it does not exist
+// cancelled elections refuse every further transaction, use readElection
+// where a cancelled election is acceptable
+func getElection(stub shim.ChaincodeStubInterface, electionId string) (*election, error) {
+	election, err := readElection(stub, electionId)
+	if err != nil {
+		return nil, err
+	}
+	if election.Status == electionCancelled {
+		return nil, fmt.Errorf("Election is cancelled: %s", electionId)
+	}
+	return election, nil
+}
+
+func readElection(stub shim.ChaincodeStubInterface, electionId string) (*election, error) {
+	electionAsBytes, err := stub.GetState(electionId)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get election: %s", electionId)
+	}
+	if electionAsBytes == nil {
+		return nil, fmt.Errorf("Election does not exist: %s", electionId)
+	}
+	election := &election{}
+	json.Unmarshal(electionAsBytes, election)
+	return election, nil
+}
+
+// transaction timestamp in RFC3339
+// unlike time.Now() it is the same on every endorsing peer
+func getTxTimestamp(stub shim.ChaincodeStubInterface) (string, error) {
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("Failed to get transaction timestamp")
+	}
+	return timestamp.AsTime().UTC().Format(time.RFC3339), nil
 }
 
 // get election by id function
@@ -319,9 +1036,10 @@ func (t *VotingChaincode) getAllElections(stub shim.ChaincodeStubInterface) pb.R
 }
 
 // create election function
+// an optional 6th argument holds the electionOptions JSON document
 func (t *VotingChaincode) createElection(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	if len(args) != 5 {
-		return shim.Error("Incorrect number of arguments. Expecting 3")
+	if len(args) != 5 && len(args) != 6 {
+		return shim.Error("Incorrect number of arguments. Expecting 5 or 6")
 	}
 	electionName := args[0]
 	startDate := args[1]
@@ -342,29 +1060,100 @@ func (t *VotingChaincode) createElection(stub shim.ChaincodeStubInterface, args
 	// electionID := "election." + strconv.Itoa(time.Now().Nanosecond())
 	// createdAt := time.Now().String()
 
-	if startDate > endDate {
-		return shim.Error("Invalid election dates")
+	options := electionOptions{}
+	if len(args) == 6 && args[5] != "" {
+		err := json.Unmarshal([]byte(args[5]), &options)
+		if err != nil {
+			return shim.Error("Invalid election options: " + err.Error())
+		}
 	}
 
-	// generate unique election id
-	var election = &election{electionID, electionName, startDate, endDate, createdAt, nil}
-	electionAsBytes, _ := json.Marshal(election)
-	err := stub.PutState(electionID, electionAsBytes)
+	election, err := newElection(stub, electionID, electionName, startDate, endDate, createdAt, options)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	_, err = putElection(stub, election)
 	if err != nil {
 		fmt.Println("Error creating election")
 		return shim.Error(err.Error())
 	}
 
-	fmt.Println("election creation successful %s", electionID)
+	fmt.Printf("election creation successful %s\n", electionID)
 	return shim.Success(nil)
 }
 
+// validate a new election and its options
+// the election is not stored, callers put it once everything else checks out
+func newElection(stub shim.ChaincodeStubInterface, electionID string, electionName string, startDate string, endDate string, createdAt string, options electionOptions) (*election, error) {
+	if startDate > endDate {
+		return nil, fmt.Errorf("Invalid election dates")
+	}
+
+	// reject IDs that are already used, a runoff or another election would be overwritten
+	existingAsBytes, err := stub.GetState(electionID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get election: %s", electionID)
+	}
+	if existingAsBytes != nil {
+		return nil, fmt.Errorf("Election already exists: %s", electionID)
+	}
+
+	if options.TieBreak == "" {
+		options.TieBreak = tieBreakCommittee
+	}
+	err = validateTieBreak(options.TieBreak, options.SeedHash)
+	if err != nil {
+		return nil, err
+	}
+	if options.MajorityThreshold < 0 || options.MajorityThreshold >= 100 {
+		return nil, fmt.Errorf("Invalid majority threshold")
+	}
+	if options.Type == "" {
+		options.Type = electionStandard
+	}
+	if options.Type != electionStandard && options.Type != electionWeighted {
+		return nil, fmt.Errorf("Invalid election type: %s", options.Type)
+	}
+	if options.Constituency != "" {
+		_, err := getFaculty(stub, options.Constituency)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.Seconders < 0 || (options.Seconders > 0 && !options.Nominations) {
+		return nil, fmt.Errorf("Invalid number of seconders")
+	}
+
+	// generate unique election id
+	var election = &election{
+		ElectionID:   electionID,
+		ElectionName: electionName,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		CreatedAt:    createdAt,
+		TieBreak:     options.TieBreak,
+		SeedHash:     options.SeedHash,
+		// majority threshold is a percentage of candidate votes
+		MajorityThreshold: options.MajorityThreshold,
+		Status:            electionOpen,
+		Type:              options.Type,
+		AllowRevote:       options.AllowRevote,
+		Eligibility:       options.Eligibility,
+		FrozenRoll:        options.FrozenRoll,
+		Nominations:       options.Nominations,
+		Seconders:         options.Seconders,
+		Constituency:      options.Constituency,
+		ExtendOnResume:    options.ExtendOnResume,
+	}
+	return election, nil
+}
+
 // TODO: if cadidate exists, update candidate and append electionId to candidate.Elections
 // else create candidate
 // create candidate function
 func (t *VotingChaincode) createCandidate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	if len(args) != 6 {
-		return shim.Error("Incorrect number of arguments. Expecting 3")
+	if len(args) != 6 && len(args) != 7 {
+		return shim.Error("Incorrect number of arguments. Expecting 6 or 7")
 	}
 
 	candidateName := args[0]
@@ -374,78 +1163,80 @@ func (t *VotingChaincode) createCandidate(stub shim.ChaincodeStubInterface, args
 	faculty := args[3]
 	party := args[4]
 	avatar := args[5]
+	partyId := ""
+	if len(args) == 7 {
+		partyId = args[6]
+	}
+	if err := checkAvatarUnset(avatar); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	// candidates of an election with a nomination period only reach the ballot
+	// through an approved nomination, see nomination.go
+	if election.Nominations {
+		return shim.Error("Candidates for this election must be nominated: " + electionId)
+	}
+	err = checkCandidateEntry(stub, studentId, election)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// nomination time is used by the earliest nomination tie-break
+	nominatedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 
+	err = enterCandidate(stub, candidate{StudentID: studentId, Name: candidateName, Faculty: faculty, Party: party, PartyID: partyId, Avatar: avatar}, electionId, nominatedAt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// add a candidate to an election, creating the candidate if they are new
+// the details of an existing candidate are kept
+func enterCandidate(stub shim.ChaincodeStubInterface, c candidate, electionId string, nominatedAt string) error {
+	studentId := c.StudentID
 	// check if cadidate exist
 	candidateAsBytes, err := stub.GetState(studentId)
 	if err != nil {
-		return shim.Error("Failed to get candidate: " + studentId)
+		return fmt.Errorf("Failed to get candidate: %s", studentId)
 	}
+	info := electionInfo{ElectionID: electionId, Votes: 0, NominatedAt: nominatedAt}
 	if candidateAsBytes != nil {
 		// if candidate exists, update candidate and append electionId to candidate.Elections
 		candidate := candidate{}
 		json.Unmarshal(candidateAsBytes, &candidate)
-		info := electionInfo{ElectionID: electionId, Votes: 0}
 		candidate.Elections = append(candidate.Elections, info)
 		candidateAsBytes, _ := json.Marshal(candidate)
 		err := stub.PutState(studentId, candidateAsBytes)
 		if err != nil {
 			fmt.Println("Error updating candidate")
-			return shim.Error(err.Error())
-		}
-		fmt.Println("candidate update successful %s", studentId)
-		return shim.Success(nil)
-	} else {
-		// else create candidate
-		info := electionInfo{ElectionID: electionId, Votes: 0}
-		candidate := candidate{
-			StudentID: studentId,
-			Name:      candidateName,
-			Faculty:   faculty,
-			Party:     party,
-			Avatar:    avatar,
-			Elections: []electionInfo{info}}
-		candidateAsBytes, _ := json.Marshal(candidate)
-		err := stub.PutState(studentId, candidateAsBytes)
-		if err != nil {
-			fmt.Println("Error creating candidate")
-			return shim.Error(err.Error())
+			return err
 		}
-		fmt.Println("candidate creation successful %s", studentId)
-		return shim.Success(nil)
+		fmt.Printf("candidate update successful %s\n", studentId)
+		return nil
 	}
 
-}
-
-func (t *VotingChaincode) updateElection(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	electionId := args[0]
-	target := args[1]
-	value := args[2]
-
-	electionAsBytes, err := stub.GetState(electionId)
+	// else create candidate
+	c.PartyID, c.Party, err = resolveParty(stub, c.PartyID, c.Party)
 	if err != nil {
-		return shim.Error("Failed to get election: " + electionId)
+		return err
 	}
-
-	election := election{}
-	json.Unmarshal(electionAsBytes, &election)
-
-	if target == "name" {
-		election.ElectionName = value
-	} else if target == "startDate" {
-		election.StartDate = value
-	} else if target == "endDate" {
-		election.EndDate = value
-	} else {
-		return shim.Error("Invalid target")
-	}
-
-	electionAsBytes, _ = json.Marshal(election)
-	err = stub.PutState(electionId, electionAsBytes)
+	c.Elections = []electionInfo{info}
+	candidateAsBytes, _ = json.Marshal(c)
+	err = stub.PutState(studentId, candidateAsBytes)
 	if err != nil {
-		return shim.Error(err.Error())
+		fmt.Println("Error creating candidate")
+		return err
 	}
-
-	return shim.Success(nil)
+	fmt.Printf("candidate creation successful %s\n", studentId)
+	return nil
 }
 
 // get candidates by id
@@ -489,6 +1280,11 @@ func (t *VotingChaincode) getCandidatesById(stub shim.ChaincodeStubInterface, ar
 				buffer.WriteString(queryResponse.Key)
 				buffer.WriteString("\"")
 
+				buffer.WriteString(", \"Status\":")
+				buffer.WriteString("\"")
+				buffer.WriteString(candidateStatus(election))
+				buffer.WriteString("\"")
+
 				buffer.WriteString(", \"Record\":")
 				// Record is a JSON object, so we write as-is
 				buffer.WriteString(string(candidateAsBytes))
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// election lifecycle statuses
+// elections created before statuses were introduced have an empty status
+// and are treated as open
+const (
+	electionOpen   = "open"
+	electionClosed = "closed"
+	// cancelled before results were declared, refuses every further transaction
+	electionCancelled = "cancelled"
+)
+
+// close an election once voting has ended
+// if the results require a runoff, a linked runoff election is created
+// with the qualifying candidates copied over
+// args: electionID, runoffElectionID, runoffStartDate, runoffEndDate, createdAt
+// like createElection the runoff ID and createdAt come from the REST API
+// so every endorsing peer writes the same key
+func (t *VotingChaincode) closeElection(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 5 {
+		return shim.Error("Incorrect number of arguments. Expecting 5")
+	}
+	electionId := args[0]
+	runoffId := args[1]
+	runoffStartDate := args[2]
+	runoffEndDate := args[3]
+	createdAt := args[4]
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.Status == electionClosed {
+		return shim.Error("Election is already closed")
+	}
+	// a suspended election's end date is not final until it is resumed
+	if election.Suspended {
+		return shim.Error("Election is suspended")
+	}
+	if err := electionHasEnded(stub, election); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	results, err := computeElectionResults(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if results.RunoffRequired {
+		if runoffStartDate == "" || runoffEndDate == "" || runoffStartDate > runoffEndDate {
+			return shim.Error("Invalid runoff dates")
+		}
+		runoffAsBytes, err := stub.GetState(runoffId)
+		if err != nil {
+			return shim.Error("Failed to get election: " + runoffId)
+		}
+		if runoffAsBytes != nil {
+			return shim.Error("Election already exists: " + runoffId)
+		}
+
+		runoff := newRunoffElection(election, runoffId, runoffStartDate, runoffEndDate, createdAt)
+		_, err = putElection(stub, runoff)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		for _, c := range runoffCandidates(election, results) {
+			err := enterRunoff(stub, c, election.ElectionID, runoffId)
+			if err != nil {
+				return shim.Error(err.Error())
+			}
+		}
+		// weighted runoffs keep the delegations' weights
+		if election.Type == electionWeighted {
+			err := copyVoterWeights(stub, election.ElectionID, runoffId)
+			if err != nil {
+				return shim.Error(err.Error())
+			}
+		}
+		election.RunoffElectionID = runoffId
+		fmt.Printf("runoff election created %s\n", runoffId)
+	}
+
+	election.Status = electionClosed
+	electionAsBytes, err := putElection(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(electionAsBytes)
+}
+
+// cancel an election before its results are declared
+// a cancelled election refuses every further transaction but stays queryable
+// args: electionID, reason
+func (t *VotingChaincode) cancelElection(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	electionId := args[0]
+	reason := strings.TrimSpace(args[1])
+	if reason == "" {
+		return shim.Error("A reason is required to cancel an election")
+	}
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.Status == electionClosed {
+		return shim.Error("Election results have already been declared")
+	}
+
+	cancelledAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	election.Status = electionCancelled
+	election.CancelReason = reason
+	election.CancelledAt = cancelledAt
+	electionAsBytes, err := putElection(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("election cancelled %s: %s\n", electionId, reason)
+	return shim.Success(electionAsBytes)
+}
+
+// archive an election whose results have been certified
+// so it drops out of election listings
+// args: electionID
+func (t *VotingChaincode) archiveElection(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	electionId := args[0]
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := resultsFinal(stub, election); err != nil {
+		return shim.Error("Only elections with final results can be archived: " + err.Error())
+	}
+	if election.Archived {
+		return shim.Error("Election is already archived")
+	}
+
+	archivedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	election.Archived = true
+	election.ArchivedAt = archivedAt
+	electionAsBytes, err := putElection(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("election archived %s\n", electionId)
+	return shim.Success(electionAsBytes)
+}
+
+// check that an election's results are final
+// results are final once the election is closed and its results have been
+// certified, and the certified results still match the ledger
+func resultsFinal(stub shim.ChaincodeStubInterface, e *election) error {
+	if e.Status != electionClosed {
+		return fmt.Errorf("Election is not closed")
+	}
+	if !e.Certified {
+		return fmt.Errorf("Election results have not been certified")
+	}
+	_, hash, err := electionResultsDocument(stub, e)
+	if err != nil {
+		return err
+	}
+	if hash != e.ResultsHash {
+		return fmt.Errorf("Election results have changed since they were certified")
+	}
+	return nil
+}
+
+// get every election, leaving out archived elections unless include is "archived"
+// args: [include]
+func (t *VotingChaincode) getElections(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) > 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 0 or 1")
+	}
+	includeArchived := len(args) == 1 && args[0] == "archived"
+
+	electionsIterator, err := stub.GetStateByRange("election.", "election.z")
+	if err != nil {
+		return shim.Error("Failed to get elections")
+	}
+	defer electionsIterator.Close()
+
+	results := []queryResult{}
+	for electionsIterator.HasNext() {
+		queryResponse, err := electionsIterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		e := election{}
+		json.Unmarshal(queryResponse.Value, &e)
+		if e.Archived && !includeArchived {
+			continue
+		}
+		results = append(results, queryResult{Key: queryResponse.Key, Record: queryResponse.Value})
+	}
+	resultsAsBytes, _ := json.Marshal(results)
+	return shim.Success(resultsAsBytes)
+}
+
+// runoff election inheriting the settings of the election it replaces
+func newRunoffElection(e *election, runoffId string, startDate string, endDate string, createdAt string) *election {
+	// a revealed lot seed cannot be reused and another runoff would never end,
+	// so those ties go to the committee
+	tieBreak := e.TieBreak
+	if tieBreak == tieBreakLot || tieBreak == tieBreakRunoff {
+		tieBreak = tieBreakCommittee
+	}
+	// the runoff votes on the roll frozen for the original election
+	rollOf := ""
+	if e.FrozenRoll {
+		rollOf = e.ElectionID
+		if e.RollOf != "" {
+			rollOf = e.RollOf
+		}
+	}
+	return &election{
+		ElectionID:   runoffId,
+		ElectionName: e.ElectionName + " (Runoff)",
+		StartDate:    startDate,
+		EndDate:      endDate,
+		CreatedAt:    createdAt,
+		TieBreak:     tieBreak,
+		// the runoff is decided by plurality
+		MajorityThreshold: 0,
+		Status:            electionOpen,
+		RunoffOf:          e.ElectionID,
+		Type:              e.Type,
+		AllowRevote:       e.AllowRevote,
+		Eligibility:       e.Eligibility,
+		Constituency:      e.Constituency,
+		ExtendOnResume:    e.ExtendOnResume,
+		FrozenRoll:        e.FrozenRoll,
+		RollFrozen:        e.RollFrozen,
+		RollFrozenAt:      e.RollFrozenAt,
+		RollSize:          e.RollSize,
+		RollOf:            rollOf,
+	}
+}
+
+// candidates going through to the runoff
+// all candidates tied for first, otherwise the top two
+// and anyone tied with second place
+func runoffCandidates(e *election, results *electionResults) []candidate {
+	candidates := append([]candidate{}, results.Candidates...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidateVotes(candidates[i], e) > candidateVotes(candidates[j], e)
+	})
+
+	qualifying := 2
+	if results.Tie != nil {
+		qualifying = len(results.Tie.Candidates)
+	}
+	if len(candidates) <= qualifying {
+		return candidates
+	}
+	cutoff := candidateVotes(candidates[qualifying-1], e)
+	for qualifying < len(candidates) && candidateVotes(candidates[qualifying], e) == cutoff {
+		qualifying++
+	}
+	return candidates[:qualifying]
+}
+
+// add a runoff entry to a candidate, keeping their original nomination time
+func enterRunoff(stub shim.ChaincodeStubInterface, c candidate, electionId string, runoffId string) error {
+	info := electionInfo{ElectionID: runoffId, Votes: 0, NominatedAt: nominationTime(c, electionId)}
+	c.Elections = append(c.Elections, info)
+	candidateAsBytes, _ := json.Marshal(c)
+	return stub.PutState(c.StudentID, candidateAsBytes)
+}
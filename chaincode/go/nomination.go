@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// nomination status
+// a nomination is seconded once it has the number of seconders the election
+// requires, and only a seconded nomination can be approved
+const (
+	nominationPending  = "pending"
+	nominationSeconded = "seconded"
+	nominationApproved = "approved"
+	nominationRejected = "rejected"
+)
+
+// nominations are kept per election, see electionRecordKey
+const nominationObjectType = "nomination"
+
+// a student's nomination to stand in an election
+// the candidate only appears on the ballot once the committee approves it
+type nomination struct {
+	ElectionID string `json:"electionID"`
+	// candidate key, candidate.<studentID>
+	StudentID   string             `json:"studentID"`
+	Name        string             `json:"name"`
+	Faculty     string             `json:"faculty"`
+	Party       string             `json:"party"`
+	PartyID     string             `json:"partyID,omitempty"`
+	NominatedBy string             `json:"nominatedBy"`
+	NominatedAt string             `json:"nominatedAt"`
+	Seconds     []nominationSecond `json:"seconds"`
+	Status      string             `json:"status"`
+	DecidedAt   string             `json:"decidedAt,omitempty"`
+	Reason      string             `json:"reason,omitempty"`
+}
+
+type nominationSecond struct {
+	SeconderID string `json:"seconderID"`
+	SecondedAt string `json:"secondedAt"`
+}
+
+// submit a nomination during the election's nomination period,
+// which runs until the election opens
+// args: electionID, JSON candidate details, nominatorID, [nominatorAttributes]
+func (t *VotingChaincode) submitNomination(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 && len(args) != 4 {
+		return shim.Error("Incorrect number of arguments. Expecting 3 or 4")
+	}
+	electionId := args[0]
+	nominatorId := args[2]
+	nominatorAttributesAsJson := ""
+	if len(args) == 4 {
+		nominatorAttributesAsJson = args[3]
+	}
+
+	details := candidate{}
+	err := json.Unmarshal([]byte(args[1]), &details)
+	if err != nil {
+		return shim.Error("Invalid nomination: " + err.Error())
+	}
+	if strings.TrimSpace(details.Name) == "" || strings.TrimSpace(details.StudentID) == "" {
+		return shim.Error("Nomination requires a name and studentID")
+	}
+	if err := checkAvatarUnset(details.Avatar); err != nil {
+		return shim.Error(err.Error())
+	}
+	studentId := "candidate." + details.StudentID
+
+	election, err := getNominationElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	nominatorAttributes, err := parseVoterAttributes(nominatorAttributesAsJson)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := checkVoterEligibility(stub, election, nominatorAttributes); err != nil {
+		return shim.Error("Nominator " + strings.TrimPrefix(err.Error(), "Voter "))
+	}
+
+	err = checkCandidateEntry(stub, studentId, election)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	existing, err := getNomination(stub, electionId, studentId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	// a rejected candidate may be nominated again, the ledger history keeps the rejection
+	if existing != nil && existing.Status != nominationRejected {
+		return errorResponse(&conflictError{"Candidate has already been nominated: " + studentId})
+	}
+
+	details.PartyID, details.Party, err = resolveParty(stub, details.PartyID, details.Party)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	nominatedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	n := &nomination{
+		ElectionID:  electionId,
+		StudentID:   studentId,
+		Name:        details.Name,
+		Faculty:     details.Faculty,
+		Party:       details.Party,
+		PartyID:     details.PartyID,
+		NominatedBy: nominatorId,
+		NominatedAt: nominatedAt,
+		Seconds:     []nominationSecond{},
+		Status:      nominationPending,
+	}
+	if election.Seconders == 0 {
+		n.Status = nominationSeconded
+	}
+
+	nominationAsBytes, err := putNomination(stub, n)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("nomination submitted %s %s\n", electionId, studentId)
+	return shim.Success(nominationAsBytes)
+}
+
+// second a pending nomination
+// args: electionID, candidate studentID, seconderID, [seconderAttributes]
+func (t *VotingChaincode) secondNomination(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 && len(args) != 4 {
+		return shim.Error("Incorrect number of arguments. Expecting 3 or 4")
+	}
+	electionId := args[0]
+	studentId := "candidate." + args[1]
+	seconderId := args[2]
+	seconderAttributesAsJson := ""
+	if len(args) == 4 {
+		seconderAttributesAsJson = args[3]
+	}
+
+	election, err := getNominationElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	seconderAttributes, err := parseVoterAttributes(seconderAttributesAsJson)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := checkVoterEligibility(stub, election, seconderAttributes); err != nil {
+		return shim.Error("Seconder " + strings.TrimPrefix(err.Error(), "Voter "))
+	}
+
+	n, err := getNomination(stub, electionId, studentId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if n == nil {
+		return shim.Error("Nomination not found: " + studentId)
+	}
+	if n.Status != nominationPending {
+		return shim.Error("Nomination is " + n.Status)
+	}
+	if n.NominatedBy == seconderId {
+		return shim.Error("Nominator cannot second their own nomination")
+	}
+	for _, second := range n.Seconds {
+		if second.SeconderID == seconderId {
+			return shim.Error("Nomination has already been seconded by this student")
+		}
+	}
+
+	secondedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	n.Seconds = append(n.Seconds, nominationSecond{SeconderID: seconderId, SecondedAt: secondedAt})
+	if len(n.Seconds) >= election.Seconders {
+		n.Status = nominationSeconded
+	}
+
+	nominationAsBytes, err := putNomination(stub, n)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("nomination seconded %s %s %d/%d\n", electionId, studentId, len(n.Seconds), election.Seconders)
+	return shim.Success(nominationAsBytes)
+}
+
+// record the committee's decision on a nomination, only committee members may decide
+// an approved nominee is entered into the election as a candidate
+// args: electionID, candidate studentID, approve|reject, reason
+func (t *VotingChaincode) decideNomination(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 4 {
+		return shim.Error("Incorrect number of arguments. Expecting 4")
+	}
+	electionId := args[0]
+	studentId := "candidate." + args[1]
+	decision := args[2]
+	reason := strings.TrimSpace(args[3])
+
+	if decision != "approve" && decision != "reject" {
+		return shim.Error("Decision must be approve or reject")
+	}
+	if decision == "reject" && reason == "" {
+		return shim.Error("A rejected nomination requires a reason")
+	}
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getNominationElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	n, err := getNomination(stub, electionId, studentId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if n == nil {
+		return shim.Error("Nomination not found: " + studentId)
+	}
+	if n.Status == nominationApproved || n.Status == nominationRejected {
+		return shim.Error("Nomination has already been " + n.Status)
+	}
+
+	decidedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	n.DecidedAt = decidedAt
+	n.Reason = reason
+	if decision == "reject" {
+		n.Status = nominationRejected
+	} else {
+		if n.Status != nominationSeconded {
+			return shim.Error("Nomination does not have enough seconders")
+		}
+		// the candidate may have been entered for a conflicting position since the nomination
+		err = checkCandidateEntry(stub, n.StudentID, election)
+		if err != nil {
+			return errorResponse(err)
+		}
+		n.Status = nominationApproved
+		// the nomination time, not the approval, is used by the earliest nomination tie-break
+		c := candidate{StudentID: n.StudentID, Name: n.Name, Faculty: n.Faculty, Party: n.Party, PartyID: n.PartyID}
+		err = enterCandidate(stub, c, electionId, n.NominatedAt)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	nominationAsBytes, err := putNomination(stub, n)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("nomination %s %s %s\n", n.Status, electionId, studentId)
+	return shim.Success(nominationAsBytes)
+}
+
+// get every nomination submitted for an election
+// args: electionID
+func (t *VotingChaincode) getNominations(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	electionId := args[0]
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(nominationObjectType, []string{electionId})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer resultsIterator.Close()
+
+	nominations := []nomination{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		n := nomination{}
+		json.Unmarshal(queryResponse.Value, &n)
+		nominations = append(nominations, n)
+	}
+
+	nominationsAsBytes, _ := json.Marshal(nominations)
+	return shim.Success(nominationsAsBytes)
+}
+
+// get an election that is in its nomination period
+func getNominationElection(stub shim.ChaincodeStubInterface, electionId string) (*election, error) {
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return nil, err
+	}
+	if !election.Nominations {
+		return nil, fmt.Errorf("Election does not take nominations: %s", electionId)
+	}
+	if election.Status == electionClosed {
+		return nil, fmt.Errorf("Election is closed")
+	}
+	started, err := electionHasStarted(stub, election)
+	if err != nil {
+		return nil, err
+	}
+	if started {
+		return nil, fmt.Errorf("Nomination period has ended")
+	}
+	return election, nil
+}
+
+// get a nomination, returns nil if the candidate has not been nominated
+func getNomination(stub shim.ChaincodeStubInterface, electionId string, studentId string) (*nomination, error) {
+	key, err := electionRecordKey(stub, nominationObjectType, electionId, studentId)
+	if err != nil {
+		return nil, err
+	}
+	nominationAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get nomination: %s", studentId)
+	}
+	if nominationAsBytes == nil {
+		return nil, nil
+	}
+	n := &nomination{}
+	json.Unmarshal(nominationAsBytes, n)
+	return n, nil
+}
+
+func putNomination(stub shim.ChaincodeStubInterface, n *nomination) ([]byte, error) {
+	key, err := electionRecordKey(stub, nominationObjectType, n.ElectionID, n.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	nominationAsBytes, _ := json.Marshal(n)
+	return nominationAsBytes, stub.PutState(key, nominationAsBytes)
+}
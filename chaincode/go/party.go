@@ -0,0 +1,302 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// a registered party or slate
+// candidates link to it by PartyID, Party on the candidate keeps the
+// party's name for clients that only read the free-text field
+type party struct {
+	PartyID      string `json:"partyID"`
+	Name         string `json:"name"`
+	Abbreviation string `json:"abbreviation"`
+	Logo         string `json:"logo"`
+	// sha256 hex of the party manifesto
+	ManifestoHash string `json:"manifestoHash"`
+	RegisteredAt  string `json:"registeredAt"`
+}
+
+// votes and seats won by a party across the positions of a ballot
+// candidates without a registered party are grouped with an empty PartyID
+type partyResult struct {
+	PartyID       string   `json:"partyID"`
+	Name          string   `json:"name"`
+	Abbreviation  string   `json:"abbreviation,omitempty"`
+	Candidates    []string `json:"candidates"`
+	Votes         int      `json:"votes"`
+	WeightedVotes int      `json:"weightedVotes"`
+	Seats         []string `json:"seats"`
+}
+
+type partyResults struct {
+	BallotID  string        `json:"ballotID,omitempty"`
+	Elections []string      `json:"elections"`
+	Parties   []partyResult `json:"parties"`
+}
+
+// register a party
+// names and abbreviations are compared ignoring case, spaces and punctuation,
+// so "Pro-Mahasiswa" and "pro mahasiswa" cannot both be registered
+// args: partyID, JSON party
+func (t *VotingChaincode) registerParty(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	partyId := args[0]
+	if !strings.HasPrefix(partyId, "party.") {
+		return shim.Error("Invalid party ID: " + partyId)
+	}
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	p := party{}
+	err = json.Unmarshal([]byte(args[1]), &p)
+	if err != nil {
+		return shim.Error("Invalid party: " + err.Error())
+	}
+	p.PartyID = partyId
+	p.Name = strings.TrimSpace(p.Name)
+	p.Abbreviation = strings.TrimSpace(p.Abbreviation)
+	if p.Name == "" || p.Abbreviation == "" {
+		return shim.Error("Party requires a name and abbreviation")
+	}
+	if p.ManifestoHash != "" && !isSha256Hex(p.ManifestoHash) {
+		return shim.Error("Manifesto hash must be a sha256 hex digest")
+	}
+
+	parties, err := getParties(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	for _, existing := range parties {
+		if existing.PartyID == partyId {
+			return errorResponse(&conflictError{"Party already exists: " + partyId})
+		}
+		if partyMatches(existing, p.Name) || partyMatches(existing, p.Abbreviation) {
+			return errorResponse(&conflictError{"Party is already registered as " + existing.Name + " (" + existing.Abbreviation + ")"})
+		}
+	}
+
+	p.RegisteredAt, err = getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	partyAsBytes, _ := json.Marshal(p)
+	err = stub.PutState(partyId, partyAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("party registered %s %s\n", partyId, p.Abbreviation)
+	return shim.Success(partyAsBytes)
+}
+
+// get every registered party
+func (t *VotingChaincode) getAllParties(stub shim.ChaincodeStubInterface) pb.Response {
+	parties, err := getParties(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	partiesAsBytes, _ := json.Marshal(parties)
+	return shim.Success(partiesAsBytes)
+}
+
+// get a party by ID
+// args: partyID
+func (t *VotingChaincode) getPartyById(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	p, err := getParty(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	partyAsBytes, _ := json.Marshal(p)
+	return shim.Success(partyAsBytes)
+}
+
+// votes and seats per party for an election
+// an election created from a ballot definition is aggregated with the other
+// positions of its ballot, so a slate's results cover every position it contested
+// args: electionID
+func (t *VotingChaincode) getPartyResults(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	e, err := getElection(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	elections := []*election{e}
+	if e.BallotID != "" {
+		elections, err = getBallotElections(stub, e.BallotID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	parties, err := getParties(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	results := &partyResults{BallotID: e.BallotID, Elections: []string{}, Parties: []partyResult{}}
+	// index of each party in results.Parties
+	partyIndex := map[string]int{}
+	partyOf := func(c candidate) *partyResult {
+		i, ok := partyIndex[c.PartyID]
+		if !ok {
+			result := partyResult{PartyID: c.PartyID, Name: "Independent", Candidates: []string{}, Seats: []string{}}
+			for _, p := range parties {
+				if p.PartyID == c.PartyID {
+					result.Name = p.Name
+					result.Abbreviation = p.Abbreviation
+				}
+			}
+			i = len(results.Parties)
+			partyIndex[c.PartyID] = i
+			results.Parties = append(results.Parties, result)
+		}
+		return &results.Parties[i]
+	}
+
+	for _, e := range elections {
+		electionResults, err := computeElectionResults(stub, e)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		results.Elections = append(results.Elections, e.ElectionID)
+		for _, c := range electionResults.Candidates {
+			result := partyOf(c)
+			result.Candidates = append(result.Candidates, c.StudentID)
+			for _, info := range c.Elections {
+				if info.ElectionID == e.ElectionID {
+					result.Votes += info.Votes
+					result.WeightedVotes += info.WeightedVotes
+				}
+			}
+		}
+		if electionResults.Winner.StudentID != "" {
+			result := partyOf(electionResults.Winner)
+			result.Seats = append(result.Seats, e.ElectionID)
+		}
+	}
+
+	resultsAsBytes, _ := json.Marshal(results)
+	return shim.Success(resultsAsBytes)
+}
+
+// find the registered party a candidate belongs to
+// partyId is checked when given, otherwise a party whose name or abbreviation
+// matches the free-text party is used; a party that is not registered is kept as free text
+func resolveParty(stub shim.ChaincodeStubInterface, partyId string, partyName string) (string, string, error) {
+	if partyId != "" {
+		p, err := getParty(stub, partyId)
+		if err != nil {
+			return "", "", err
+		}
+		return p.PartyID, p.Name, nil
+	}
+	if strings.TrimSpace(partyName) == "" {
+		return "", partyName, nil
+	}
+	parties, err := getParties(stub)
+	if err != nil {
+		return "", "", err
+	}
+	for _, p := range parties {
+		if partyMatches(p, partyName) {
+			return p.PartyID, p.Name, nil
+		}
+	}
+	return "", partyName, nil
+}
+
+func getParty(stub shim.ChaincodeStubInterface, partyId string) (*party, error) {
+	partyAsBytes, err := stub.GetState(partyId)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get party: %s", partyId)
+	}
+	if partyAsBytes == nil || !strings.HasPrefix(partyId, "party.") {
+		return nil, fmt.Errorf("Party does not exist: %s", partyId)
+	}
+	p := &party{}
+	json.Unmarshal(partyAsBytes, p)
+	return p, nil
+}
+
+func getParties(stub shim.ChaincodeStubInterface) ([]party, error) {
+	partiesIterator, err := stub.GetStateByRange("party.", "party.z")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get parties")
+	}
+	defer partiesIterator.Close()
+
+	parties := []party{}
+	for partiesIterator.HasNext() {
+		queryResponse, err := partiesIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		p := party{}
+		json.Unmarshal(queryResponse.Value, &p)
+		parties = append(parties, p)
+	}
+	return parties, nil
+}
+
+// elections created from the same ballot definition
+func getBallotElections(stub shim.ChaincodeStubInterface, ballotId string) ([]*election, error) {
+	electionsIterator, err := stub.GetStateByRange("election.", "election.z")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get elections: %s", ballotId)
+	}
+	defer electionsIterator.Close()
+
+	elections := []*election{}
+	for electionsIterator.HasNext() {
+		queryResponse, err := electionsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		e := &election{}
+		json.Unmarshal(queryResponse.Value, e)
+		if e.BallotID == ballotId && e.Status != electionCancelled {
+			elections = append(elections, e)
+		}
+	}
+	return elections, nil
+}
+
+// match a name against a party's name or abbreviation
+func partyMatches(p party, name string) bool {
+	key := nameKey(name)
+	return key != "" && (key == nameKey(p.Name) || key == nameKey(p.Abbreviation))
+}
+
+// names compared ignoring case, spaces and punctuation
+func nameKey(name string) string {
+	var key strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			key.WriteRune(r)
+		}
+	}
+	return key.String()
+}
+
+func isSha256Hex(value string) bool {
+	hash, err := hex.DecodeString(value)
+	return err == nil && len(hash) == sha256.Size
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// candidate details that can be changed after creation
+// fields left empty keep their current value
+type candidateProfile struct {
+	Name    string `json:"name"`
+	Faculty string `json:"faculty"`
+	Party   string `json:"party"`
+	PartyID string `json:"partyID"`
+	Avatar  string `json:"avatar"`
+}
+
+// update a candidate's profile before any of their elections opens
+// submitted by the candidate or by a committee member on their behalf
+// every version stays on the ledger, see getCandidateHistory
+// args: studentID, JSON candidate profile
+func (t *VotingChaincode) updateCandidate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	studentId := "candidate." + args[0]
+
+	profile := candidateProfile{}
+	err := json.Unmarshal([]byte(args[1]), &profile)
+	if err != nil {
+		return shim.Error("Invalid candidate profile: " + err.Error())
+	}
+
+	candidate, err := getCandidate(stub, studentId)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = assertCandidateOrCommittee(stub, candidate)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = assertProfileEditable(stub, candidate)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if name := strings.TrimSpace(profile.Name); name != "" {
+		candidate.Name = name
+	}
+	if profile.Faculty != "" {
+		candidate.Faculty = profile.Faculty
+	}
+	if profile.Party != "" || profile.PartyID != "" {
+		candidate.PartyID, candidate.Party, err = resolveParty(stub, profile.PartyID, profile.Party)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	if err := checkAvatarUnset(profile.Avatar); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	candidateAsBytes, _ := json.Marshal(candidate)
+	err = stub.PutState(studentId, candidateAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("candidate profile updated %s\n", studentId)
+	return shim.Success(candidateAsBytes)
+}
+
+// uploaded avatar, the REST API serves it from Avatar after checking its bytes
+type candidateAvatar struct {
+	Avatar string `json:"avatar"`
+	Hash   string `json:"hash"`
+}
+
+// record an uploaded avatar and its sha256 on a candidate
+// submitted by the candidate or by a committee member on their behalf
+// args: studentID, JSON candidate avatar
+func (t *VotingChaincode) setCandidateAvatar(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	studentId := "candidate." + args[0]
+
+	avatar := candidateAvatar{}
+	err := json.Unmarshal([]byte(args[1]), &avatar)
+	if err != nil {
+		return shim.Error("Invalid candidate avatar: " + err.Error())
+	}
+	avatar.Hash = strings.ToLower(avatar.Hash)
+	if !isSha256Hex(avatar.Hash) {
+		return shim.Error("Avatar hash must be a sha256 hex digest")
+	}
+	if avatar.Avatar == "" {
+		return shim.Error("Candidate avatar requires a URL")
+	}
+
+	candidate, err := getCandidate(stub, studentId)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = assertCandidateOrCommittee(stub, candidate)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = assertProfileEditable(stub, candidate)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	candidate.Avatar = avatar.Avatar
+	candidate.AvatarHash = avatar.Hash
+
+	candidateAsBytes, _ := json.Marshal(candidate)
+	err = stub.PutState(studentId, candidateAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("candidate avatar set %s %s\n", studentId, avatar.Hash)
+	return shim.Success(candidateAsBytes)
+}
+
+// get a candidate by student ID
+// args: studentID
+func (t *VotingChaincode) getCandidateById(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	candidate, err := getCandidate(stub, "candidate."+args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	candidateAsBytes, _ := json.Marshal(candidate)
+	return shim.Success(candidateAsBytes)
+}
+
+// get every version of a candidate, oldest first
+// args: studentID
+func (t *VotingChaincode) getCandidateHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	history, err := keyHistory(stub, "candidate."+args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	historyAsBytes, _ := json.Marshal(history)
+	return shim.Success(historyAsBytes)
+}
+
+// avatars are only set by uploading them, see setCandidateAvatar, so every
+// avatar has been checked and resized by the REST API and is pinned by its hash
+func checkAvatarUnset(avatar string) error {
+	if avatar != "" {
+		return fmt.Errorf("Candidate avatars must be uploaded, see setCandidateAvatar")
+	}
+	return nil
+}
+
+// check that a candidate's profile can still change
+// voters see the profile on the ballot, so it is fixed once an election the
+// candidate stands in has opened, cancelled elections aside
+func assertProfileEditable(stub shim.ChaincodeStubInterface, c *candidate) error {
+	for _, info := range c.Elections {
+		e, err := readElection(stub, info.ElectionID)
+		if err != nil {
+			return err
+		}
+		if e.Status == electionCancelled {
+			continue
+		}
+		if e.Status == electionClosed {
+			return fmt.Errorf("Candidate profile cannot change, election is closed: %s", e.ElectionID)
+		}
+		started, err := electionHasStarted(stub, e)
+		if err != nil {
+			return err
+		}
+		if started {
+			return fmt.Errorf("Candidate profile cannot change once the election opens: %s", e.ElectionID)
+		}
+	}
+	return nil
+}
+
+func getCandidate(stub shim.ChaincodeStubInterface, studentId string) (*candidate, error) {
+	candidateAsBytes, err := stub.GetState(studentId)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get candidate: %s", studentId)
+	}
+	if candidateAsBytes == nil {
+		return nil, &notFoundError{fmt.Sprintf("Candidate does not exist: %s", studentId)}
+	}
+	candidate := &candidate{}
+	json.Unmarshal(candidateAsBytes, candidate)
+	return candidate, nil
+}
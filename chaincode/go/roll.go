@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// voter roll entries are kept per election, see electionRecordKey
+const rollObjectType = "roll"
+
+// hashed voter ID stored in an election's voter roll
+// the election ID is part of the hash so rolls cannot be joined across elections
+func voterRollHash(electionId string, Id string) string {
+	hash := sha256.Sum256([]byte(electionId + "." + Id))
+	return hex.EncodeToString(hash[:])
+}
+
+// add hashed voter IDs to an election's voter roll
+// the roll is built in batches by the REST API once the election opens and then frozen
+// args: electionID, JSON array of voter roll hashes
+func (t *VotingChaincode) addVoterRollEntries(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	electionId := args[0]
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	hashes := []string{}
+	err = json.Unmarshal([]byte(args[1]), &hashes)
+	if err != nil {
+		return shim.Error("Invalid voter roll entries: " + err.Error())
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !election.FrozenRoll {
+		return shim.Error("Election does not use a frozen voter roll")
+	}
+	if election.RollFrozen {
+		return shim.Error("Voter roll is already frozen")
+	}
+	started, err := electionHasStarted(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !started {
+		return shim.Error("Voter roll can only be built once the election opens")
+	}
+
+	added := map[string]bool{}
+	for _, hash := range hashes {
+		decoded, err := hex.DecodeString(hash)
+		if err != nil || len(decoded) != sha256.Size {
+			return shim.Error("Invalid voter roll hash: " + hash)
+		}
+		if added[hash] {
+			continue
+		}
+		key, err := electionRecordKey(stub, rollObjectType, electionId, hash)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		existing, err := stub.GetState(key)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		// a voter added by an earlier batch is not counted twice
+		if existing != nil {
+			continue
+		}
+		err = stub.PutState(key, []byte{0x00})
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		added[hash] = true
+	}
+
+	election.RollSize += len(added)
+	_, err = putElection(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("voter roll %s: %d added, %d total\n", electionId, len(added), election.RollSize)
+	return shim.Success(nil)
+}
+
+// freeze an election's voter roll and publish its size once the election opens
+// ballots are rejected until the roll is frozen and no voter can be added afterwards
+// args: electionID
+func (t *VotingChaincode) freezeVoterRoll(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	electionId := args[0]
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !election.FrozenRoll {
+		return shim.Error("Election does not use a frozen voter roll")
+	}
+	if election.RollFrozen {
+		return shim.Error("Voter roll is already frozen")
+	}
+	// the roll is the electorate at opening, a snapshot taken earlier would
+	// leave out students who became eligible in the meantime
+	started, err := electionHasStarted(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !started {
+		return shim.Error("Voter roll can only be frozen once the election opens")
+	}
+
+	frozenAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	election.RollFrozen = true
+	election.RollFrozenAt = frozenAt
+	electionAsBytes, err := putElection(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("voter roll frozen %s: %d voters\n", electionId, election.RollSize)
+	return shim.Success(electionAsBytes)
+}
+
+// check that a voter is in an election's frozen voter roll
+// elections without a frozen roll accept every voter
+func checkVoterRoll(stub shim.ChaincodeStubInterface, e *election, Id string) error {
+	if !e.FrozenRoll {
+		return nil
+	}
+	if !e.RollFrozen {
+		return fmt.Errorf("Voter roll has not been frozen")
+	}
+	// a runoff uses the roll frozen for the election it replaces
+	rollElectionId := e.ElectionID
+	if e.RollOf != "" {
+		rollElectionId = e.RollOf
+	}
+	key, err := electionRecordKey(stub, rollObjectType, rollElectionId, voterRollHash(rollElectionId, Id))
+	if err != nil {
+		return err
+	}
+	entry, err := stub.GetState(key)
+	if err != nil {
+		return fmt.Errorf("Failed to get voter roll: %s", e.ElectionID)
+	}
+	if entry == nil {
+		return fmt.Errorf("Voter is not in the voter roll")
+	}
+	return nil
+}
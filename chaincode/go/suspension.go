@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// a period during which an election refused ballots
+type suspension struct {
+	Reason       string `json:"reason"`
+	SuspendedBy  string `json:"suspendedBy"`
+	SuspendedAt  string `json:"suspendedAt"`
+	ResumeReason string `json:"resumeReason,omitempty"`
+	ResumedBy    string `json:"resumedBy,omitempty"`
+	ResumedAt    string `json:"resumedAt,omitempty"`
+	// voting time lost to the suspension added to the end date on resume
+	Extension string `json:"extension,omitempty"`
+}
+
+// suspend voting in an election, eg when the auth database or a polling
+// station has been compromised
+// ballots are rejected until the election is resumed
+// args: electionID, reason
+func (t *VotingChaincode) suspendElection(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	electionId := args[0]
+	reason := strings.TrimSpace(args[1])
+	if reason == "" {
+		return shim.Error("A reason is required to suspend an election")
+	}
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.Status == electionClosed {
+		return shim.Error("Election is closed")
+	}
+	if election.Suspended {
+		return shim.Error("Election is already suspended")
+	}
+	if electionHasEnded(stub, election) == nil {
+		return shim.Error("Election has ended")
+	}
+
+	suspendedBy, err := submitterIdentity(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	suspendedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	election.Suspended = true
+	election.Suspensions = append(election.Suspensions, suspension{
+		Reason:      reason,
+		SuspendedBy: suspendedBy,
+		SuspendedAt: suspendedAt,
+	})
+	electionAsBytes, err := putElection(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("election suspended %s: %s\n", electionId, reason)
+	return shim.Success(electionAsBytes)
+}
+
+// resume a suspended election
+// elections created with extendOnResume have their end date pushed back
+// by the voting time lost to the suspension
+// args: electionID, reason
+func (t *VotingChaincode) resumeElection(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	electionId := args[0]
+	reason := strings.TrimSpace(args[1])
+	if reason == "" {
+		return shim.Error("A reason is required to resume an election")
+	}
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !election.Suspended || len(election.Suspensions) == 0 {
+		return shim.Error("Election is not suspended")
+	}
+
+	resumedBy, err := submitterIdentity(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	resumedAt, err := getTxTimestamp(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	s := &election.Suspensions[len(election.Suspensions)-1]
+	s.ResumeReason = reason
+	s.ResumedBy = resumedBy
+	s.ResumedAt = resumedAt
+
+	if election.ExtendOnResume {
+		lost, err := suspendedVotingTime(election, s)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if lost > 0 {
+			endDate, _ := time.Parse(time.RFC3339, election.EndDate)
+			election.EndDate = endDate.Add(lost).UTC().Format(time.RFC3339)
+			election.UpdatedAt = &resumedAt
+			s.Extension = lost.String()
+		}
+	}
+	election.Suspended = false
+
+	electionAsBytes, err := putElection(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("election resumed %s, ends %s\n", electionId, election.EndDate)
+	return shim.Success(electionAsBytes)
+}
+
+// part of a suspension that fell inside the election's voting window
+func suspendedVotingTime(e *election, s *suspension) (time.Duration, error) {
+	startDate, err := time.Parse(time.RFC3339, e.StartDate)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to parse election start date: %s", e.StartDate)
+	}
+	endDate, err := time.Parse(time.RFC3339, e.EndDate)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to parse election end date: %s", e.EndDate)
+	}
+	suspendedAt, err := time.Parse(time.RFC3339, s.SuspendedAt)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to parse suspension time: %s", s.SuspendedAt)
+	}
+	resumedAt, err := time.Parse(time.RFC3339, s.ResumedAt)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to parse resume time: %s", s.ResumedAt)
+	}
+	if suspendedAt.Before(startDate) {
+		suspendedAt = startDate
+	}
+	if resumedAt.After(endDate) {
+		resumedAt = endDate
+	}
+	if !resumedAt.After(suspendedAt) {
+		return 0, nil
+	}
+	return resumedAt.Sub(suspendedAt), nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// tie-break policies an election can declare
+const (
+	// tied candidates go to a runoff election
+	tieBreakRunoff = "runoff"
+	// lot drawn from a seed whose hash is committed before voting
+	tieBreakLot = "lot"
+	// candidate nominated first wins
+	tieBreakEarliestNomination = "earliestNomination"
+	// the committee picks the winner with a decideTie transaction
+	tieBreakCommittee = "committee"
+)
+
+// how a tie was handled by the tally
+// DecidedBy is empty while the tie is unresolved
+type tieBreakResult struct {
+	Candidates []string `json:"candidates"`
+	Votes      int      `json:"votes"`
+	Policy     string   `json:"policy"`
+	Resolved   bool     `json:"resolved"`
+	DecidedBy  string   `json:"decidedBy,omitempty"`
+	Reason     string   `json:"reason,omitempty"`
+}
+
+// check a tie-break policy when the election is created
+func validateTieBreak(policy string, seedHash string) error {
+	switch policy {
+	case tieBreakRunoff, tieBreakEarliestNomination, tieBreakCommittee:
+		return nil
+	case tieBreakLot:
+		// the seed must be committed before any ballot is cast
+		// otherwise whoever holds it could pick the winner after the fact
+		hash, err := hex.DecodeString(seedHash)
+		if err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("Lot tie-break requires a sha256 seed hash")
+		}
+		return nil
+	}
+	return fmt.Errorf("Invalid tie-break policy: %s", policy)
+}
+
+// apply the election's tie-break policy to the tied candidates
+// returns the winner, or an empty candidate if the tie is still unresolved
+func applyTieBreak(e *election, tied []candidate) (candidate, *tieBreakResult) {
+	tie := &tieBreakResult{Policy: e.TieBreak, Votes: candidateVotes(tied[0], e)}
+	for _, c := range tied {
+		tie.Candidates = append(tie.Candidates, c.StudentID)
+	}
+
+	winner := candidate{}
+	switch e.TieBreak {
+	case tieBreakRunoff:
+		tie.Reason = "Runoff election required"
+		return winner, tie
+	case tieBreakLot:
+		if e.Seed == "" {
+			tie.Reason = "Awaiting lot seed"
+			return winner, tie
+		}
+		// each candidate draws sha256(seed + studentID), the lowest draw wins
+		// so anyone holding the revealed seed can repeat the draw
+		lowest := ""
+		for _, c := range tied {
+			draw := sha256.Sum256([]byte(e.Seed + c.StudentID))
+			drawHex := hex.EncodeToString(draw[:])
+			if lowest == "" || drawHex < lowest {
+				lowest = drawHex
+				winner = c
+			}
+		}
+	case tieBreakEarliestNomination:
+		earliest := ""
+		shared := false
+		for _, c := range tied {
+			nominatedAt := nominationTime(c, e.ElectionID)
+			if nominatedAt == "" {
+				tie.Reason = "Nomination time missing for " + c.StudentID
+				return candidate{}, tie
+			}
+			if earliest == "" || nominatedAt < earliest {
+				earliest = nominatedAt
+				winner = c
+				shared = false
+			} else if nominatedAt == earliest {
+				shared = true
+			}
+		}
+		if shared {
+			tie.Reason = "Candidates were nominated at the same time"
+			return candidate{}, tie
+		}
+	case tieBreakCommittee:
+		for _, c := range tied {
+			if c.StudentID == e.CommitteeDecision {
+				winner = c
+			}
+		}
+		if winner.StudentID == "" {
+			tie.Reason = "Awaiting committee decision"
+			return winner, tie
+		}
+	default:
+		tie.Reason = "No tie-break policy"
+		return winner, tie
+	}
+
+	tie.Resolved = true
+	tie.DecidedBy = e.TieBreak
+	return winner, tie
+}
+
+// time a candidate was nominated for an election
+func nominationTime(c candidate, electionId string) string {
+	for _, info := range c.Elections {
+		if info.ElectionID == electionId {
+			return info.NominatedAt
+		}
+	}
+	return ""
+}
+
+// check that voting has ended, tie-break inputs are only accepted afterwards
+func electionHasEnded(stub shim.ChaincodeStubInterface, e *election) error {
+	electionEndDate, err := time.Parse(time.RFC3339, e.EndDate)
+	if err != nil {
+		return fmt.Errorf("Failed to parse election end date: %s", e.EndDate)
+	}
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp")
+	}
+	if timestamp.AsTime().Before(electionEndDate) {
+		return fmt.Errorf("Election has not ended")
+	}
+	return nil
+}
+
+// reveal the lot seed committed when the election was created
+// args: electionID, seed
+func (t *VotingChaincode) revealTieBreakSeed(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	electionId := args[0]
+	seed := args[1]
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.TieBreak != tieBreakLot {
+		return shim.Error("Election does not break ties by lot")
+	}
+	if election.Seed != "" {
+		return shim.Error("Seed has already been revealed")
+	}
+	if err := electionHasEnded(stub, election); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	hash := sha256.Sum256([]byte(seed))
+	if hex.EncodeToString(hash[:]) != election.SeedHash {
+		return shim.Error("Seed does not match the committed seed hash")
+	}
+
+	election.Seed = seed
+	_, err = putElection(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// record the committee's decision on a tie, only committee members may decide
+// args: electionID, candidateID
+func (t *VotingChaincode) decideTie(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	electionId := args[0]
+	candidateId := args[1]
+
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.TieBreak != tieBreakCommittee {
+		return shim.Error("Election does not break ties by committee decision")
+	}
+	if election.CommitteeDecision != "" {
+		return shim.Error("Committee has already decided the tie")
+	}
+	if err := electionHasEnded(stub, election); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// the decision has to pick one of the tied candidates
+	results, err := computeElectionResults(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if results.Tie == nil {
+		return shim.Error("Election is not tied")
+	}
+	isTied := false
+	for _, studentId := range results.Tie.Candidates {
+		if studentId == candidateId {
+			isTied = true
+		}
+	}
+	if !isTied {
+		return shim.Error("Candidate is not tied for first place: " + candidateId)
+	}
+
+	election.CommitteeDecision = candidateId
+	_, err = putElection(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// a row of a bulk voter import
+type voterRow struct {
+	StudentID string `json:"studentID"`
+	Email     string `json:"email"`
+	Faculty   string `json:"faculty"`
+}
+
+// outcome of a row of a bulk voter import
+// status is created, exists (already registered for this election),
+// duplicate (repeated in the import) or invalid
+type voterRowResult struct {
+	StudentID string `json:"studentID"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// registrations are kept per election, see electionRecordKey, so a student
+// registered for one election can still be registered for another
+const registrationObjectType = "registration"
+
+// store a voter's registration for an election
+// returns false if the student is already registered for this election
+func registerVoter(stub shim.ChaincodeStubInterface, studentId string, electionId string, email string, faculty string) (bool, error) {
+	existing, _, err := getVoterRegistration(stub, electionId, studentId)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		return false, nil
+	}
+
+	key, err := electionRecordKey(stub, registrationObjectType, electionId, studentId)
+	if err != nil {
+		return false, err
+	}
+	var newVoter = voter{StudentID: "voter." + studentId, HasVoted: false, ElectionID: electionId, Email: email, Faculty: faculty}
+	newVoterAsBytes, _ := json.Marshal(newVoter)
+	err = stub.PutState(key, newVoterAsBytes)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// get a voter's registration for an election and the key it is stored under
+// voters registered before registrations were kept per election are stored
+// under the bare student ID and only match the election they were registered for
+// returns a nil voter and the key to store a new registration under if the
+// student is not registered for the election
+func getVoterRegistration(stub shim.ChaincodeStubInterface, electionId string, studentId string) (*voter, string, error) {
+	key, err := electionRecordKey(stub, registrationObjectType, electionId, studentId)
+	if err != nil {
+		return nil, "", err
+	}
+	voterAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to get voter: %s", studentId)
+	}
+	if voterAsBytes != nil {
+		v := &voter{}
+		json.Unmarshal(voterAsBytes, v)
+		return v, key, nil
+	}
+
+	legacyAsBytes, err := stub.GetState(studentId)
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to get voter: %s", studentId)
+	}
+	if legacyAsBytes != nil {
+		v := &voter{}
+		json.Unmarshal(legacyAsBytes, v)
+		if v.ElectionID == electionId {
+			return v, studentId, nil
+		}
+	}
+	return nil, key, nil
+}
+
+// register a batch of voters for an election in one transaction
+// voters already registered for the election are reported as exists rather than failing
+// the batch, so an interrupted import can be re-run from the start
+// args: electionID, JSON array of voterRow
+func (t *VotingChaincode) createVoters(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+	electionId := args[0]
+
+	rows := []voterRow{}
+	err := json.Unmarshal([]byte(args[1]), &rows)
+	if err != nil {
+		return shim.Error("Invalid voter rows: " + err.Error())
+	}
+
+	if _, err := getElection(stub, electionId); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	results := []voterRowResult{}
+	// repeats within the batch are caught here, not by GetState
+	seen := map[string]bool{}
+	for _, row := range rows {
+		result := voterRowResult{StudentID: row.StudentID}
+		if strings.TrimSpace(row.StudentID) == "" || !strings.Contains(row.Email, "@") || strings.TrimSpace(row.Faculty) == "" {
+			result.Status = "invalid"
+			result.Error = "studentID, email and faculty are required"
+			results = append(results, result)
+			continue
+		}
+		if seen[row.StudentID] {
+			result.Status = "duplicate"
+			results = append(results, result)
+			continue
+		}
+		seen[row.StudentID] = true
+
+		created, err := registerVoter(stub, row.StudentID, electionId, row.Email, row.Faculty)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if created {
+			result.Status = "created"
+		} else {
+			result.Status = "exists"
+		}
+		results = append(results, result)
+	}
+
+	resultsAsBytes, _ := json.Marshal(results)
+	return shim.Success(resultsAsBytes)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// election types
+// in a weighted election each ballot counts for the weight the admin
+// assigned to the voter, eg a delegation's share of assembly membership
+const (
+	electionStandard = "standard"
+	electionWeighted = "weighted"
+)
+
+// assign a voter's weight for a weighted election before it opens
+// args: voterID, electionID, weight
+func (t *VotingChaincode) setVoterWeight(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return shim.Error("Incorrect number of arguments. Expecting 3")
+	}
+	Id := args[0]
+	electionId := args[1]
+	err := assertCommittee(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	weight, err := strconv.Atoi(args[2])
+	if err != nil || weight <= 0 {
+		return shim.Error("Weight must be a positive integer")
+	}
+
+	election, err := getElection(stub, electionId)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if election.Type != electionWeighted {
+		return shim.Error("Election is not weighted: " + electionId)
+	}
+	// weights are fixed before the election opens, so no ballot is cast
+	// under a weight that changes afterwards
+	started, err := electionHasStarted(stub, election)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if started {
+		return shim.Error("Voter weights can only be set before the election opens")
+	}
+
+	VoterID := "voter." + Id
+	voterAsBytes, err := stub.GetState(VoterID)
+	if err != nil {
+		return shim.Error("Failed to get voter: " + VoterID)
+	}
+	voter := voterV2{ID: Id}
+	if voterAsBytes != nil {
+		json.Unmarshal(voterAsBytes, &voter)
+	}
+
+	// the weight of a ballot already counted cannot change
+	eligibility := findEligibility(&voter, electionId)
+	if eligibility == nil {
+		voter.ElectionEligibility = append(voter.ElectionEligibility, ElectionEligibility{ElectionID: electionId, Weight: weight})
+	} else if eligibility.HasVoted {
+		return shim.Error("Voter has already voted")
+	} else {
+		eligibility.Weight = weight
+	}
+
+	voterAsBytes, _ = json.Marshal(voter)
+	err = stub.PutState(VoterID, voterAsBytes)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fmt.Printf("voter weight set %s %d\n", VoterID, weight)
+	return shim.Success(nil)
+}
+
+// give every weighted voter of an election the same weight in its runoff
+func copyVoterWeights(stub shim.ChaincodeStubInterface, electionId string, runoffId string) error {
+	votersIterator, err := stub.GetStateByRange("voter.", "voter.z")
+	if err != nil {
+		return fmt.Errorf("Failed to get voters: %s", electionId)
+	}
+	defer votersIterator.Close()
+
+	for votersIterator.HasNext() {
+		queryResponse, err := votersIterator.Next()
+		if err != nil {
+			return err
+		}
+		voter := voterV2{}
+		json.Unmarshal(queryResponse.Value, &voter)
+		eligibility := findEligibility(&voter, electionId)
+		if eligibility == nil || eligibility.Weight <= 0 {
+			continue
+		}
+		voter.ElectionEligibility = append(voter.ElectionEligibility, ElectionEligibility{ElectionID: runoffId, Weight: eligibility.Weight})
+		voterAsBytes, _ := json.Marshal(voter)
+		err = stub.PutState(queryResponse.Key, voterAsBytes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}